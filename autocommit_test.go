@@ -0,0 +1,101 @@
+package sqlanywhere
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+)
+
+func TestAutocommit(t *testing.T) {
+	testdb := NewTestDB(t)
+	defer testdb.Cleanup()
+
+	db, close := testdb.Open()
+	defer close()
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec("create table autocommit_test (id int primary key)"); err != nil {
+		t.Fatalf("did not create table: %v", err)
+	}
+
+	t.Run("durable outside transaction", func(t *testing.T) {
+		if _, err := db.Exec("insert into autocommit_test values (1)"); err != nil {
+			t.Fatal(err)
+		}
+
+		//a rollback must not undo a statement executed outside a transaction
+		if _, err := db.Exec("rollback"); err != nil {
+			t.Fatal(err)
+		}
+
+		if count := countRows(t, db, "autocommit_test"); count != 1 {
+			t.Fatalf("want 1 row committed, got %d", count)
+		}
+	})
+
+	t.Run("off during transaction", func(t *testing.T) {
+		tx, err := db.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := tx.Exec("insert into autocommit_test values (2)"); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := tx.Rollback(); err != nil {
+			t.Fatal(err)
+		}
+
+		if count := countRows(t, db, "autocommit_test"); count != 1 {
+			t.Fatalf("want insert in transaction rolled back, got %d rows", count)
+		}
+
+		//autocommit is back on, so a later rollback does not undo the insert
+		if _, err := db.Exec("insert into autocommit_test values (2)"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.Exec("rollback"); err != nil {
+			t.Fatal(err)
+		}
+		if count := countRows(t, db, "autocommit_test"); count != 2 {
+			t.Fatalf("want autocommit on after rollback, got %d rows", count)
+		}
+	})
+
+	t.Run("chained mode", func(t *testing.T) {
+		connector, err := NewConnector(testdb.ConnectionString(), WithChainedMode())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		chained := sql.OpenDB(connector)
+		defer chained.Close()
+		chained.SetMaxOpenConns(1)
+
+		conn, err := chained.Conn(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+
+		if _, err := conn.ExecContext(context.Background(), "insert into autocommit_test values (3)"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := conn.ExecContext(context.Background(), "rollback"); err != nil {
+			t.Fatal(err)
+		}
+
+		if count := countRows(t, db, "autocommit_test"); count != 1 {
+			t.Fatalf("want uncommitted insert rolled back in chained mode, got %d rows", count)
+		}
+	})
+}
+
+func countRows(t *testing.T, db *sql.DB, table string) int {
+	var count int
+	if err := db.QueryRow("select count(*) from " + table).Scan(&count); err != nil {
+		t.Fatalf("did not count rows in %s: %v", table, err)
+	}
+	return count
+}
@@ -0,0 +1,46 @@
+//go:build linux && leakcheck
+// +build linux,leakcheck
+
+package sqlanywhere
+
+import (
+	"strings"
+	"testing"
+)
+
+//TestBindBufferLeak executes a prepared statement a million times, checking the C heap stays flat
+func TestBindBufferLeak(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping million executions in short mode")
+	}
+
+	testdb := NewTestDB(t)
+	defer testdb.Cleanup()
+
+	db, close := testdb.Open()
+	defer close()
+
+	stmt := prepareBindTest(t, db)
+	defer stmt.Close()
+
+	const executions = 1000000
+	const warmup = 10000
+	const slack = 1 << 20
+
+	var start uint64
+	for i := 0; i < executions; i++ {
+		if i == warmup {
+			start = cHeapInUse()
+		}
+
+		//vary the lengths, so buffers are grown and reused
+		name := strings.Repeat("x", i%100)
+		if _, err := stmt.Exec(int64(i), 1.5, true, name, []byte(name)); err != nil {
+			t.Fatalf("execution %d: %v", i, err)
+		}
+	}
+
+	if end := cHeapInUse(); end > start+slack {
+		t.Fatalf("want C heap flat, grew from %d to %d bytes", start, end)
+	}
+}
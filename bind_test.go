@@ -0,0 +1,138 @@
+package sqlanywhere
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+// TestBindEmptyFirst binds empty values in the first execution of a prepared statement,
+// before any buffer has been grown for them
+func TestBindEmptyFirst(t *testing.T) {
+	testdb := NewTestDB(t)
+	defer testdb.Cleanup()
+
+	db, close := testdb.Open()
+	defer close()
+
+	if _, err := db.Exec("create table empty_test (id int primary key, s varchar(10) null, b varbinary(10) null)"); err != nil {
+		t.Fatal(err)
+	}
+
+	stmt, err := db.Prepare("insert into empty_test values (?, ?, ?)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.Exec(1, "", []byte{}); err != nil {
+		t.Fatalf("did not bind empty values: %v", err)
+	}
+	if _, err := stmt.Exec(2, "grown", []byte("grown")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stmt.Exec(3, "", []byte{}); err != nil {
+		t.Fatalf("did not bind empty values after growing: %v", err)
+	}
+
+	for _, id := range []int{1, 3} {
+		var s *string
+		var b []byte
+		if err := db.QueryRow("select s, b from empty_test where id = ?", id).Scan(&s, &b); err != nil {
+			t.Fatal(err)
+		}
+		if s == nil || *s != "" || b == nil || len(b) != 0 {
+			t.Fatalf("row %d: want empty, not null, values, got %v %v", id, s, b)
+		}
+	}
+}
+
+//BenchmarkParamBuffer compares binding into a reused buffer with the previous strategy of
+//allocating and freeing a buffer for each value bound
+func BenchmarkParamBuffer(b *testing.B) {
+	value := []byte(strings.Repeat("x", 100))
+
+	b.Run("allocate per bind", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var buffer paramBuffer
+			if err := buffer.alloc(); err != nil {
+				b.Fatal(err)
+			}
+			bindBuffer(b, &buffer, value)
+			buffer.free()
+		}
+	})
+
+	b.Run("reuse", func(b *testing.B) {
+		var buffer paramBuffer
+		if err := buffer.alloc(); err != nil {
+			b.Fatal(err)
+		}
+		defer buffer.free()
+
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			bindBuffer(b, &buffer, value)
+		}
+	})
+}
+
+func bindBuffer(b *testing.B, buffer *paramBuffer, value []byte) {
+	buf, err := buffer.bytes(len(value))
+	if err != nil {
+		b.Fatal(err)
+	}
+	copy(buf, value)
+}
+
+func BenchmarkPreparedExec(b *testing.B) {
+	testdb := NewTestDB(b)
+	defer testdb.Cleanup()
+
+	db, close := testdb.Open()
+	defer close()
+
+	stmt := prepareBindTest(b, db)
+	defer stmt.Close()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := stmt.Exec(int64(i), 1.5, true, "name", []byte("name")); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+//prepareBindTest prepares a statement binding each kind of parameter, affecting no rows
+func prepareBindTest(t testing.TB, db *sql.DB) *sql.Stmt {
+	//a single connection, so the statement is prepared once
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec("create table bind_test (i bigint, f double, b bit, s varchar(100), bs varbinary(100))"); err != nil {
+		t.Fatal(err)
+	}
+
+	stmt, err := db.Prepare("delete from bind_test where i = ? and f = ? and b = ? and s = ? and bs = ?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stmt
+}
+
+func TestParamBufferTooLong(t *testing.T) {
+	var buffer paramBuffer
+	if err := buffer.alloc(); err != nil {
+		t.Fatal(err)
+	}
+	defer buffer.free()
+
+	if _, err := buffer.bytes(maxParamLength + 1); err == nil {
+		t.Fatal("want error binding a value longer than the maximum")
+	}
+	if buf, err := buffer.bytes(3); err != nil || len(buf) != 3 {
+		t.Fatalf("want buffer unchanged and usable, got %d bytes: %v", len(buf), err)
+	}
+}
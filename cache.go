@@ -0,0 +1,180 @@
+package sqlanywhere
+
+import (
+	"container/list"
+	"context"
+	"strconv"
+	"sync/atomic"
+)
+
+//StatementCacheStats counts the use of the per connection prepared statement caches, across all connections
+type StatementCacheStats struct {
+	Hits          uint64
+	Misses        uint64
+	Evictions     uint64
+	Invalidations uint64
+}
+
+//HitRate is the fraction of lookups found in a cache, or zero if there have been none
+func (s StatementCacheStats) HitRate() float64 {
+	lookups := s.Hits + s.Misses
+	if lookups == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(lookups)
+}
+
+var cacheStats StatementCacheStats
+
+//ReadStatementCacheStats returns the current statement cache counts
+func ReadStatementCacheStats() StatementCacheStats {
+	return StatementCacheStats{
+		Hits:          atomic.LoadUint64(&cacheStats.Hits),
+		Misses:        atomic.LoadUint64(&cacheStats.Misses),
+		Evictions:     atomic.LoadUint64(&cacheStats.Evictions),
+		Invalidations: atomic.LoadUint64(&cacheStats.Invalidations),
+	}
+}
+
+//schemaChangeCodes are errors showing a cached statement refers to objects that no longer exist as prepared
+var schemaChangeCodes = map[int]bool{
+	-141: true, //table not found
+	-143: true, //column not found
+	-265: true, //procedure not found
+}
+
+//stmtCache is a least recently used cache of prepared statements for a connection, keyed by SQL
+type stmtCache struct {
+	size  int
+	order *list.List //of *statement, most recently used at the front
+	items map[string]*list.Element
+}
+
+func newStmtCache(size int) *stmtCache {
+	return &stmtCache{
+		size:  size,
+		order: list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+//get returns the cached statement for query, or nil if there is none
+func (c *stmtCache) get(query string) *statement {
+	element, ok := c.items[query]
+	if !ok {
+		return nil
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*statement)
+}
+
+//put caches the statement, evicting the least recently used statement if the cache is full
+func (c *stmtCache) put(stmt *statement) {
+	if _, ok := c.items[stmt.query]; ok {
+		return
+	}
+
+	if c.order.Len() >= c.size {
+		c.evict(c.order.Back())
+		atomic.AddUint64(&cacheStats.Evictions, 1)
+	}
+
+	stmt.cached = true
+	c.items[stmt.query] = c.order.PushFront(stmt)
+}
+
+//remove removes the statement for query from the cache, freeing it unless in use
+func (c *stmtCache) remove(query string) {
+	if element, ok := c.items[query]; ok {
+		c.evict(element)
+	}
+}
+
+//evict removes a statement from the cache. A statement still in use is freed when released.
+func (c *stmtCache) evict(element *list.Element) {
+	stmt := c.order.Remove(element).(*statement)
+	delete(c.items, stmt.query)
+
+	stmt.cached = false
+	if !stmt.inUse {
+		stmt.Close()
+	}
+}
+
+//clear frees all cached statements
+func (c *stmtCache) clear() {
+	for c.order.Len() > 0 {
+		c.evict(c.order.Back())
+	}
+}
+
+//initStatementCache creates the connection's statement cache, if enabled by the connector.
+//The size is limited to half the server's max_statement_count, leaving room for other statements.
+func (con *connection) initStatementCache() error {
+	if con.connector == nil || con.connector.statementCacheSize <= 0 {
+		return nil
+	}
+
+	size := con.connector.statementCacheSize
+
+	value, err := con.option("max_statement_count")
+	if err != nil {
+		return err
+	}
+	if max, err := strconv.Atoi(value); err == nil && max > 0 && size > max/2 {
+		size = max / 2
+	}
+
+	if size > 0 {
+		con.cache = newStmtCache(size)
+	}
+	return nil
+}
+
+//prepareCached returns a prepared statement for query, from the cache if possible.
+//The statement must be given back with release.
+func (con *connection) prepareCached(ctx context.Context, query string) (*statement, error) {
+	if con.cache == nil {
+		return con.prepareContext(ctx, query)
+	}
+
+	if stmt := con.cache.get(query); stmt != nil && !stmt.inUse {
+		atomic.AddUint64(&cacheStats.Hits, 1)
+		stmt.inUse = true
+		return stmt, nil
+	}
+	atomic.AddUint64(&cacheStats.Misses, 1)
+
+	stmt, err := con.prepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	//a statement for the same query may be in use, by open rows; the new one is then not cached
+	if con.cache.get(query) == nil {
+		con.cache.put(stmt)
+	}
+	stmt.inUse = true
+	return stmt, nil
+}
+
+//release gives back a statement from prepareCached, freeing it unless cached.
+//A cached statement that failed because the schema changed is removed from the cache.
+func (stmt *statement) release(err error) {
+	stmt.inUse = false
+
+	if stmt.cached && isSchemaChange(err) {
+		stmt.con.cache.remove(stmt.query)
+		atomic.AddUint64(&cacheStats.Invalidations, 1)
+		return
+	}
+
+	if !stmt.cached {
+		stmt.Close()
+	}
+}
+
+func isSchemaChange(err error) bool {
+	de, ok := err.(*DriverError)
+	return ok && schemaChangeCodes[de.code]
+}
@@ -0,0 +1,59 @@
+package sqlanywhere
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestStatementCache(t *testing.T) {
+	testdb := NewTestDB(t)
+	defer testdb.Cleanup()
+
+	connector, err := NewConnector(testdb.ConnectionString(), WithStatementCache(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := sql.OpenDB(connector)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec("create table cache_test (id int primary key, name varchar(20))"); err != nil {
+		t.Fatal(err)
+	}
+
+	before := ReadStatementCacheStats()
+
+	//more executions than the server allows open statements, so an uncached leak would fail
+	for i := 0; i < 1000; i++ {
+		if _, err := db.Exec("insert into cache_test values (?, ?)", i, "name"); err != nil {
+			t.Fatalf("insert %d: %v", i, err)
+		}
+		var name string
+		if err := db.QueryRow("select name from cache_test where id = ?", i).Scan(&name); err != nil {
+			t.Fatalf("select %d: %v", i, err)
+		}
+	}
+
+	after := ReadStatementCacheStats()
+	if hits := after.Hits - before.Hits; hits < 1990 {
+		t.Fatalf("want cached statements reused, got %d hits", hits)
+	}
+
+	//the cached statements refer to the old table
+	if _, err := db.Exec("drop table cache_test"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("insert into cache_test values (?, ?)", 1, "name"); !isSchemaChange(err) {
+		t.Fatalf("want table not found, got %v", err)
+	}
+	if _, err := db.Exec("create table cache_test (id int primary key, name varchar(20))"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("insert into cache_test values (?, ?)", 1, "name"); err != nil {
+		t.Fatalf("want statement prepared again after invalidation: %v", err)
+	}
+
+	if ReadStatementCacheStats().Invalidations == after.Invalidations {
+		t.Fatal("want invalidated statement")
+	}
+}
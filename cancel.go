@@ -0,0 +1,229 @@
+package sqlanywhere
+
+/*
+#include <driver.c>
+#include <stdint.h>
+
+extern void driverWaitCallback(uintptr_t handle);
+extern int driverValidateFileTransfer(uintptr_t handle, char *file_name, int is_write);
+extern void driverMessageCallback(uintptr_t handle, int msg_type, int sqlcode, char *msg, int length);
+
+//driver_thread_handle identifies the connection with a request in progress on this thread
+static __thread uintptr_t driver_thread_handle;
+
+static void driver_set_thread_handle(uintptr_t handle) {
+	driver_thread_handle = handle;
+}
+
+//driver_wait_callback is called repeatedly by the client library while a request is in progress
+static void driver_wait_callback(void *sqlca) {
+	if (driver_thread_handle != 0) {
+		driverWaitCallback(driver_thread_handle);
+	}
+}
+
+static sacapi_bool driver_register_wait(a_sqlany_connection *conn) {
+	return sqlany_register_callback(conn, CALLBACK_WAIT, (SQLANY_CALLBACK_PARM)driver_wait_callback);
+}
+
+//driver_validate_file_transfer is called by the client library before a client file is transferred
+//for a statement executed indirectly, such as from a procedure. Non zero permits the transfer.
+static int driver_validate_file_transfer(void *sqlca, char *file_name, int is_write) {
+	if (driver_thread_handle == 0) {
+		return 0;
+	}
+	return driverValidateFileTransfer(driver_thread_handle, file_name, is_write);
+}
+
+static sacapi_bool driver_register_validate_file_transfer(a_sqlany_connection *conn) {
+	return sqlany_register_callback(conn, CALLBACK_VALIDATE_FILE_TRANSFER, (SQLANY_CALLBACK_PARM)driver_validate_file_transfer);
+}
+
+//driver_message_callback is called by the client library with each message from the server during a request
+static void driver_message_callback(a_sqlany_connection *conn, a_sqlany_message_type msg_type, int sqlcode, unsigned short length, char *msg) {
+	if (driver_thread_handle != 0) {
+		driverMessageCallback(driver_thread_handle, msg_type, sqlcode, msg, length);
+	}
+}
+
+static sacapi_bool driver_register_message(a_sqlany_connection *conn) {
+	return sqlany_register_callback(conn, CALLBACK_MESSAGE, (SQLANY_CALLBACK_PARM)driver_message_callback);
+}
+*/
+import "C"
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+//CancelStrategy is how a request is interrupted when its context finishes
+type CancelStrategy int
+
+const (
+	//CancelWithCallback checks the context from the client library's wait callback,
+	//on the thread making the request, without starting a goroutine per request.
+	CancelWithCallback CancelStrategy = iota
+
+	//CancelWithGoroutine waits for the request in a new goroutine per request,
+	//cancelling it when the context finishes first.
+	CancelWithGoroutine
+)
+
+//handles maps connection handles to connections with registered callbacks
+var (
+	handles    sync.Map
+	lastHandle uintptr
+)
+
+//registerCallbacks registers the wait callback for the connection, if it cancels with callbacks,
+//the file transfer validation callback, if it has a file transfer policy, and the message callback,
+//if it has a message handler
+func (con *connection) registerCallbacks() error {
+	if con.connector == nil || con.connector.cancelStrategy == CancelWithCallback {
+		if C.driver_register_wait(con.ptr) == 0 {
+			return con.lasterr("did not register wait callback")
+		}
+		con.waitRegistered = true
+	}
+
+	registered := con.waitRegistered
+	if con.connector != nil && con.connector.fileTransferPolicy != nil {
+		if C.driver_register_validate_file_transfer(con.ptr) == 0 {
+			return con.lasterr("did not register file transfer validation callback")
+		}
+		registered = true
+	}
+
+	if con.connector != nil && con.connector.messageHandler != nil {
+		if C.driver_register_message(con.ptr) == 0 {
+			return con.lasterr("did not register message callback")
+		}
+		registered = true
+	}
+
+	if !registered {
+		return nil
+	}
+
+	con.handle = atomic.AddUintptr(&lastHandle, 1)
+	handles.Store(con.handle, con)
+	return nil
+}
+
+//unregisterCallbacks forgets the connection's handle, once the connection is closed
+func (con *connection) unregisterCallbacks() {
+	if con.handle != 0 {
+		handles.Delete(con.handle)
+		con.handle = 0
+	}
+}
+
+//handleConnection returns the connection with the handle, if it is still open
+func handleConnection(handle uintptr) (*connection, bool) {
+	value, ok := handles.Load(handle)
+	if !ok {
+		return nil, false
+	}
+	return value.(*connection), true
+}
+
+//waitCallback cancels the request in progress on the connection if its context has finished.
+//It is called from the client library on the goroutine making the request.
+func waitCallback(handle uintptr) {
+	con, ok := handleConnection(handle)
+	if !ok {
+		return
+	}
+
+	if con.waitCtx == nil || con.waitCancelled || con.waitCtx.Err() == nil {
+		return
+	}
+
+	con.waitCancelled = true
+	con.cancel()
+}
+
+//awaitFunc runs a function with opportunity to cancel via the given context
+func (con *connection) awaitFunc(ctx context.Context, run func() error) error {
+	// avoid waiting on the context if it cannot be cancelled.
+	if ctx.Done() == nil {
+		return con.onThread(run)
+	}
+
+	if con.waitRegistered {
+		return con.awaitCallback(ctx, run)
+	}
+	return con.awaitGoroutine(ctx, run)
+}
+
+//onThread runs a function with the connection's handle set for the current thread, so callbacks
+//from the client library during the function find the connection. The goroutine is locked to its
+//thread while it runs. Without registered callbacks the function is simply run.
+func (con *connection) onThread(run func() error) error {
+	if con.handle == 0 {
+		return run()
+	}
+
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	C.driver_set_thread_handle(C.uintptr_t(con.handle))
+	defer C.driver_set_thread_handle(0)
+
+	return run()
+}
+
+//awaitCallback runs a function on the current goroutine, cancelling it from the wait callback
+//if the context finishes first.
+func (con *connection) awaitCallback(ctx context.Context, run func() error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	con.waitCtx, con.waitCancelled = ctx, false
+	err := con.onThread(run)
+	con.waitCtx = nil
+
+	if err != nil && ctx.Err() != nil {
+		//the request was cancelled, or the server aborted it, as the context finished
+		return ctx.Err()
+	}
+	return err
+}
+
+//awaitGoroutine runs a function in a new goroutine, cancelling it if the context finishes first
+func (con *connection) awaitGoroutine(ctx context.Context, run func() error) error {
+	if ctx.Done() == nil {
+		return con.onThread(run)
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	done := make(chan error)
+
+	go func() {
+		done <- con.onThread(run)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil && ctx.Err() != nil {
+			//the server aborted the request as the context finished
+			return ctx.Err()
+		}
+		return err
+
+	case <-ctx.Done():
+		//context finished first; send cancellation to interrupt 'run' function
+		con.cancel()
+
+		//wait for interrupted run to finish, but discard it's error
+		discardErr := <-done
+		_ = discardErr
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,29 @@
+package sqlanywhere
+
+//#include <stdint.h>
+import "C"
+
+//driverWaitCallback is called by the C wait callback; see waitCallback.
+//It is kept apart because files exporting functions to C may only declare C functions.
+//
+//export driverWaitCallback
+func driverWaitCallback(handle C.uintptr_t) {
+	waitCallback(uintptr(handle))
+}
+
+//driverValidateFileTransfer is called by the C file transfer validation callback; see validateFileTransfer.
+//
+//export driverValidateFileTransfer
+func driverValidateFileTransfer(handle C.uintptr_t, fileName *C.char, isWrite C.int) C.int {
+	if validateFileTransfer(uintptr(handle), C.GoString(fileName), isWrite != 0) {
+		return 1
+	}
+	return 0
+}
+
+//driverMessageCallback is called by the C message callback; see messageCallback.
+//
+//export driverMessageCallback
+func driverMessageCallback(handle C.uintptr_t, msgType C.int, sqlcode C.int, msg *C.char, length C.int) {
+	messageCallback(uintptr(handle), MessageType(msgType), int(sqlcode), C.GoStringN(msg, length))
+}
@@ -0,0 +1,82 @@
+package sqlanywhere
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+)
+
+var cancelStrategies = []struct {
+	name     string
+	strategy CancelStrategy
+}{
+	{"callback", CancelWithCallback},
+	{"goroutine", CancelWithGoroutine},
+}
+
+func TestCancel(t *testing.T) {
+	testdb := NewTestDB(t)
+	defer testdb.Cleanup()
+
+	for _, test := range cancelStrategies {
+		t.Run(test.name, func(t *testing.T) {
+			db := openWithCancelStrategy(t, testdb, test.strategy)
+			defer db.Close()
+			db.SetMaxOpenConns(1)
+
+			//cancel without a deadline, so there is no server side timeout to fall back on
+			ctx, cancel := context.WithCancel(context.Background())
+			timer := time.AfterFunc(500*time.Millisecond, cancel)
+			defer timer.Stop()
+
+			start := time.Now()
+			_, err := db.ExecContext(ctx, "WAITFOR DELAY '00:00:10'")
+			elapsed := time.Since(start)
+
+			if err != context.Canceled {
+				t.Fatalf("want %v, got %v", context.Canceled, err)
+			}
+			if elapsed > 2*time.Second {
+				t.Fatalf("want prompt cancellation, took %v", elapsed)
+			}
+
+			var one int
+			if err := db.QueryRow("select 1").Scan(&one); err != nil || one != 1 {
+				t.Fatalf("connection unusable after cancel: %v", err)
+			}
+		})
+	}
+}
+
+func BenchmarkCancelStrategy(b *testing.B) {
+	testdb := NewTestDB(b)
+	defer testdb.Cleanup()
+
+	for _, test := range cancelStrategies {
+		b.Run(test.name, func(b *testing.B) {
+			db := openWithCancelStrategy(b, testdb, test.strategy)
+			defer db.Close()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var one int
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := db.QueryRowContext(ctx, "select 1").Scan(&one); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func openWithCancelStrategy(t testing.TB, testdb *TestDatabase, strategy CancelStrategy) *sql.DB {
+	connector, err := NewConnector(testdb.ConnectionString(), WithCancelStrategy(strategy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sql.OpenDB(connector)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mdcnz/sqlanywhere/schema"
+)
+
+//describe lists the tables and views, or describes the table, view or procedure with name
+func (s *session) describe(ctx context.Context, name string) error {
+	catalog, err := schema.Load(ctx, s.conn, nil)
+	if err != nil {
+		return err
+	}
+
+	if name == "" {
+		var cells [][]string
+		for _, t := range catalog.Tables {
+			kind := "table"
+			if t.Type == "GBL TEMP" {
+				kind = "temporary table"
+			}
+			cells = append(cells, []string{t.Owner, t.Name, kind})
+		}
+		for _, v := range catalog.Views {
+			cells = append(cells, []string{v.Owner, v.Name, "view"})
+		}
+		printTable(s.out, []string{"owner", "name", "type"}, cells, nil)
+		return nil
+	}
+
+	if t := catalog.Table(name); t != nil {
+		fmt.Fprintf(s.out, "Table %s.%s\n", t.Owner, t.Name)
+		s.describeColumns(t.Columns)
+
+		if t.PrimaryKey != nil {
+			fmt.Fprintf(s.out, "Primary key: (%s)\n", indexColumns(t.PrimaryKey))
+		}
+		for _, index := range t.Indexes {
+			kind := "Index"
+			if index.Unique {
+				kind = "Unique index"
+			}
+			fmt.Fprintf(s.out, "%s %s: (%s)\n", kind, index.Name, indexColumns(index))
+		}
+		for _, fk := range t.ForeignKeys {
+			fmt.Fprintf(s.out, "Foreign key %s: (%s) references %s.%s (%s)\n", fk.Name,
+				strings.Join(fk.Columns, ", "), fk.ReferencedOwner, fk.ReferencedTable, strings.Join(fk.ReferencedColumns, ", "))
+		}
+		for _, trigger := range t.Triggers {
+			fmt.Fprintf(s.out, "Trigger %s\n", trigger.Name)
+		}
+		return nil
+	}
+
+	if v := catalog.View(name); v != nil {
+		fmt.Fprintf(s.out, "View %s\n", v.QualifiedName())
+		s.describeColumns(v.Columns)
+		fmt.Fprintln(s.out, v.Definition)
+		return nil
+	}
+
+	if p := catalog.Procedure(name); p != nil {
+		kind := "Procedure"
+		if p.IsFunction() {
+			kind = "Function"
+		}
+		fmt.Fprintf(s.out, "%s %s.%s\n", kind, p.Owner, p.Name)
+
+		var cells [][]string
+		for _, parameter := range p.Parameters {
+			cells = append(cells, []string{parameter.Name, parameter.Mode, parameter.Type, parameter.Default})
+		}
+		printTable(s.out, []string{"parameter", "mode", "type", "default"}, cells, nil)
+		fmt.Fprintln(s.out, p.Definition)
+		return nil
+	}
+
+	return fmt.Errorf("no table, view or procedure named %s", name)
+}
+
+func (s *session) describeColumns(columns []*schema.Column) {
+	var cells [][]string
+	for _, c := range columns {
+		nullable := "not null"
+		if c.Nullable {
+			nullable = "null"
+		}
+		def := c.Default
+		if c.Computed {
+			def = "compute (" + def + ")"
+		}
+		cells = append(cells, []string{c.Name, c.Type, nullable, def})
+	}
+	printTable(s.out, []string{"column", "type", "nullable", "default"}, cells, nil)
+}
+
+func indexColumns(index *schema.Index) string {
+	names := make([]string, len(index.Columns))
+	for i, c := range index.Columns {
+		names[i] = c.Name
+		if c.Descending {
+			names[i] += " desc"
+		}
+	}
+	return strings.Join(names, ", ")
+}
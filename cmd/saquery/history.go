@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+//maxHistory is the number of statements remembered
+const maxHistory = 500
+
+//history holds the statements entered at the prompt, saved to a file between sessions
+type history struct {
+	entries []string
+	file    string
+}
+
+//openHistory reads the history file, $SAQUERY_HISTORY or .saquery_history in the home directory.
+//Without a file, the history lasts only for the session.
+func openHistory() *history {
+	h := &history{file: os.Getenv("SAQUERY_HISTORY")}
+	if h.file == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return h
+		}
+		h.file = filepath.Join(home, ".saquery_history")
+	}
+
+	data, err := ioutil.ReadFile(h.file)
+	if err != nil {
+		return h
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line != "" {
+			h.entries = append(h.entries, unescapeHistory(line))
+		}
+	}
+	if len(h.entries) > maxHistory {
+		h.entries = h.entries[len(h.entries)-maxHistory:]
+		h.rewrite()
+	}
+	return h
+}
+
+//add appends the statement to the history and its file
+func (h *history) add(entry string) {
+	if h == nil || entry == "" {
+		return
+	}
+
+	h.entries = append(h.entries, entry)
+	if len(h.entries) > maxHistory {
+		h.entries = h.entries[1:]
+	}
+
+	if h.file == "" {
+		return
+	}
+	f, err := os.OpenFile(h.file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	fmt.Fprintln(f, escapeHistory(entry))
+}
+
+//rewrite replaces the file with the entries held
+func (h *history) rewrite() {
+	var b strings.Builder
+	for _, entry := range h.entries {
+		b.WriteString(escapeHistory(entry))
+		b.WriteByte('\n')
+	}
+	ioutil.WriteFile(h.file, []byte(b.String()), 0600)
+}
+
+//get returns the last entry, or the entry numbered by args, counting from 1
+func (h *history) get(args []string) (string, error) {
+	if h == nil || len(h.entries) == 0 {
+		return "", fmt.Errorf("history is empty")
+	}
+	if len(args) == 0 {
+		return h.entries[len(h.entries)-1], nil
+	}
+
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 || n > len(h.entries) {
+		return "", fmt.Errorf("no history entry %s", args[0])
+	}
+	return h.entries[n-1], nil
+}
+
+//escapeHistory writes an entry on one line, escaping backslashes and newlines
+func escapeHistory(entry string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, "\r", `\r`).Replace(entry)
+}
+
+func unescapeHistory(line string) string {
+	var b strings.Builder
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		if c == '\\' && i+1 < len(line) {
+			i++
+			switch line[i] {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			default:
+				c = line[i]
+			}
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+//lineReader reads lines without their line endings
+type lineReader struct {
+	r *bufio.Reader
+}
+
+func newLineReader(r io.Reader) *lineReader {
+	return &lineReader{r: bufio.NewReader(r)}
+}
+
+//read returns the next line, or io.EOF after the last
+func (l *lineReader) read() (string, error) {
+	line, err := l.r.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	return strings.TrimRight(line, "\r\n"), err
+}
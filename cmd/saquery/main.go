@@ -0,0 +1,129 @@
+//Command saquery executes SQL against a SQL Anywhere database, from arguments, a file or an
+//interactive prompt, writing results as aligned tables, CSV or JSON Lines.
+//
+//Usage:
+//
+//	saquery [flags] [sql ...]
+//
+//The connection string is given with -c, or by the SQLCONNECT environment variable as for dbisql.
+//Each argument is executed as a script. Without arguments or -f, statements are read from standard
+//input, with a prompt if it is a terminal. Statements end with a semicolon or a line containing only "go".
+//
+//At the prompt, enter \? for the commands, such as \timing, \plan and \d table.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/mdcnz/sqlanywhere"
+)
+
+func main() {
+	os.Exit(run())
+}
+
+func run() int {
+	var (
+		connection      = flag.String("c", "", "connection `string`, default $SQLCONNECT")
+		file            = flag.String("f", "", "execute the statements of `file`, - for standard input")
+		format          = flag.String("o", formatTable, "output `format`: table, csv or json")
+		delimiter       = flag.String("delimiter", "", "statement `delimiter`, default ;")
+		plan            = flag.Bool("plan", false, "show the plan of each query")
+		timing          = flag.Bool("timing", false, "show the duration of each statement")
+		continueOnError = flag.Bool("continue", false, "continue after a statement fails")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: saquery [flags] [sql ...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *connection == "" {
+		*connection = os.Getenv("SQLCONNECT")
+	}
+	if *connection == "" {
+		fmt.Fprintln(os.Stderr, "saquery: no connection string; use -c or set SQLCONNECT")
+		return 2
+	}
+	if !validFormat(*format) {
+		fmt.Fprintf(os.Stderr, "saquery: unknown output format %q\n", *format)
+		return 2
+	}
+
+	connector, err := sqlanywhere.NewConnector(*connection, sqlanywhere.WithMessageHandler(func(m sqlanywhere.Message) {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", m.Type, m.Text)
+	}))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "saquery: %v\n", err)
+		return 1
+	}
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	ctx := context.Background()
+
+	//a single connection keeps connection state, such as temporary options, across statements
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "saquery: did not connect: %v\n", err)
+		return 1
+	}
+	defer conn.Close()
+
+	s := &session{
+		conn:            conn,
+		out:             os.Stdout,
+		errOut:          os.Stderr,
+		format:          *format,
+		delimiter:       *delimiter,
+		plan:            *plan,
+		timing:          *timing,
+		continueOnError: *continueOnError,
+	}
+
+	if *file == "" && flag.NArg() == 0 {
+		if interactive(os.Stdin) {
+			s.history = openHistory()
+			if err := s.repl(ctx, os.Stdin); err != nil {
+				fmt.Fprintf(os.Stderr, "saquery: %v\n", err)
+				return 1
+			}
+			return 0
+		}
+		*file = "-"
+	}
+
+	if *file != "" {
+		var script []byte
+		if *file == "-" {
+			script, err = ioutil.ReadAll(os.Stdin)
+		} else {
+			script, err = ioutil.ReadFile(*file)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "saquery: %v\n", err)
+			return 1
+		}
+		if !s.execScript(ctx, string(script)) {
+			return 1
+		}
+	}
+
+	for _, arg := range flag.Args() {
+		if !s.execScript(ctx, arg) {
+			return 1
+		}
+	}
+	return 0
+}
+
+//interactive reports whether the file is a terminal
+func interactive(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mdcnz/sqlanywhere/export"
+)
+
+//Output formats
+const (
+	formatTable = "table"
+	formatCSV   = "csv"
+	formatJSON  = "json"
+)
+
+func validFormat(format string) bool {
+	return format == formatTable || format == formatCSV || format == formatJSON
+}
+
+//writeRows writes the rows of the current result set in the format
+func writeRows(w io.Writer, rows *sql.Rows, format string) error {
+	var err error
+	switch format {
+	case formatCSV:
+		_, err = export.CSV(w, rows, nil)
+	case formatJSON:
+		_, err = export.JSONLines(w, rows, nil)
+	default:
+		err = writeTable(w, rows)
+	}
+	return err
+}
+
+//writeTable writes the rows as a table aligned in columns, with numbers aligned right
+func writeTable(w io.Writer, rows *sql.Rows) error {
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return err
+	}
+
+	header := make([]string, len(types))
+	right := make([]bool, len(types))
+	for i, t := range types {
+		header[i] = t.Name()
+	}
+
+	values := make([]interface{}, len(types))
+	dest := make([]interface{}, len(types))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+
+	var cells [][]string
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+
+		row := make([]string, len(values))
+		for i, value := range values {
+			row[i] = formatCell(value, types[i].DatabaseTypeName())
+			if value != nil {
+				right[i] = numeric(value, types[i].DatabaseTypeName())
+			}
+		}
+		cells = append(cells, row)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	printTable(w, header, cells, right)
+	fmt.Fprintf(w, "(%d %s)\n", len(cells), plural(int64(len(cells)), "row"))
+	return nil
+}
+
+//printTable writes the cells under the header, padding each column to its widest cell
+func printTable(w io.Writer, header []string, cells [][]string, right []bool) {
+	widths := make([]int, len(header))
+	for i, h := range header {
+		widths[i] = utf8.RuneCountInString(h)
+	}
+	for _, row := range cells {
+		for i, cell := range row {
+			if n := utf8.RuneCountInString(cell); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+
+	writeLine := func(row []string, right []bool) {
+		var b strings.Builder
+		for i, cell := range row {
+			if i > 0 {
+				b.WriteString(" | ")
+			}
+			pad := strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell))
+			if right != nil && right[i] {
+				b.WriteString(pad + cell)
+			} else if i < len(row)-1 {
+				b.WriteString(cell + pad)
+			} else {
+				b.WriteString(cell)
+			}
+		}
+		fmt.Fprintln(w, strings.TrimRight(" "+b.String(), " "))
+	}
+
+	writeLine(header, nil)
+	separators := make([]string, len(widths))
+	for i, width := range widths {
+		separators[i] = strings.Repeat("-", width+2)
+	}
+	fmt.Fprintln(w, strings.Join(separators, "+"))
+	for _, row := range cells {
+		writeLine(row, right)
+	}
+}
+
+//formatCell formats a value for a table, as dbisql shows it
+func formatCell(value interface{}, typeName string) string {
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case []byte:
+		return "0x" + hex.EncodeToString(v)
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32)
+	case time.Time:
+		switch typeName {
+		case "DATE":
+			return v.Format(export.DateLayout)
+		case "TIME":
+			return v.Format(export.TimeLayout)
+		}
+		return v.Format(export.TimestampLayout)
+	case string:
+		return strings.NewReplacer("\r", `\r`, "\n", `\n`, "\t", `\t`).Replace(v)
+	}
+	return fmt.Sprint(value)
+}
+
+//numeric reports whether the value is a number, aligned right in a table
+func numeric(value interface{}, typeName string) bool {
+	switch value.(type) {
+	case int64, int32, int16, uint8, uint16, uint32, uint64, float64, float32:
+		return true
+	}
+	return typeName == "DECIMAL"
+}
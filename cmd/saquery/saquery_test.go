@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mdcnz/sqlanywhere/internal/testdb"
+)
+
+func TestSession(t *testing.T) {
+	database := testdb.New(t)
+	defer database.Cleanup()
+
+	db, close := database.Open()
+	defer close()
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	var out, errOut bytes.Buffer
+	s := &session{conn: conn, out: &out, errOut: &errOut, format: formatTable}
+
+	input := `create table fruit (id int primary key, name varchar(20) null, price numeric(5, 2) null);
+insert into fruit values (1, 'apple', 1.5);
+insert into fruit values (2, null, 10);
+create procedure fruit_names()
+begin
+	select name from fruit order by id;
+end
+go
+\d fruit
+select id, name, price from fruit order by id;
+\o csv
+call fruit_names();
+\q
+select 'not executed';
+`
+	if err := s.repl(ctx, strings.NewReader(input)); err != nil {
+		t.Fatal(err)
+	}
+	if errOut.Len() > 0 {
+		t.Fatalf("unexpected errors: %s", errOut.String())
+	}
+
+	for _, want := range []string{
+		"1 row affected\n",
+		".fruit\n column | type",
+		"Primary key: (id)\n",
+		" id | name  | price\n----+-------+-------\n  1 | apple |  1.50\n  2 | NULL  | 10.00\n(2 rows)\n",
+		"name\r\napple\r\n\r\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("want output containing\n%s\ngot\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "not executed") {
+		t.Fatal("want input after \\q ignored")
+	}
+
+	errOut.Reset()
+	if s.execScript(ctx, "select 1;\nselect * from missing;") || !strings.Contains(errOut.String(), "error at line 2") {
+		t.Fatalf("want error at line 2, got %q", errOut.String())
+	}
+}
+
+func TestPrintTable(t *testing.T) {
+	var b bytes.Buffer
+	printTable(&b, []string{"id", "name"}, [][]string{{"1", "ü"}, {"100", "longer"}}, []bool{true, false})
+
+	want := " id  | name\n" +
+		"-----+--------\n" +
+		"   1 | ü\n" +
+		" 100 | longer\n"
+	if b.String() != want {
+		t.Fatalf("want\n%s\ngot\n%s", want, b.String())
+	}
+}
+
+func TestFirstWord(t *testing.T) {
+	for query, want := range map[string]string{
+		"select 1":                         "select",
+		"  -- comment\n/* block */ WITH x": "WITH",
+		"(select 1) union (select 2)":      "select",
+		"call p()":                         "call",
+		"-- only a comment":                "",
+	} {
+		if got := firstWord(query); got != want {
+			t.Errorf("%q: want %q, got %q", query, want, got)
+		}
+	}
+}
+
+func TestHistoryEscape(t *testing.T) {
+	entry := "select '\\n'\n-- comment\r\nfrom dummy;"
+	escaped := escapeHistory(entry)
+	if strings.ContainsAny(escaped, "\r\n") {
+		t.Fatalf("want one line, got %q", escaped)
+	}
+	if got := unescapeHistory(escaped); got != entry {
+		t.Fatalf("want %q, got %q", entry, got)
+	}
+}
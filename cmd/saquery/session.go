@@ -0,0 +1,345 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/mdcnz/sqlanywhere"
+	"github.com/mdcnz/sqlanywhere/internal/sqlsplit"
+)
+
+//session executes statements on a connection, writing their results
+type session struct {
+	conn   *sql.Conn
+	out    io.Writer
+	errOut io.Writer
+
+	format    string
+	delimiter string
+
+	//plan shows the plan of each query, and timing the duration of each statement
+	plan   bool
+	timing bool
+
+	continueOnError bool
+
+	//interrupt cancels the statement executing, nil unless interactive
+	interrupt chan os.Signal
+
+	history *history
+}
+
+//rowWords start statements executed as queries, which may return rows
+var rowWords = map[string]bool{
+	"SELECT":  true,
+	"WITH":    true,
+	"CALL":    true,
+	"EXEC":    true,
+	"EXECUTE": true,
+}
+
+//countWords start statements reporting the number of rows affected
+var countWords = map[string]bool{
+	"INSERT": true,
+	"UPDATE": true,
+	"DELETE": true,
+	"MERGE":  true,
+}
+
+//planWords start statements whose plan is shown
+var planWords = map[string]bool{
+	"SELECT": true,
+	"WITH":   true,
+}
+
+//execScript executes the statements of the script, reporting errors.
+//It returns false if a statement failed.
+func (s *session) execScript(ctx context.Context, script string) bool {
+	statements, err := sqlsplit.SplitDelimited(script, s.delimiter)
+	if err != nil {
+		fmt.Fprintf(s.errOut, "error: %v\n", err)
+		return false
+	}
+
+	ok := true
+	for _, statement := range statements {
+		if err := s.execute(ctx, statement.SQL); err != nil {
+			ok = false
+			if len(statements) > 1 {
+				fmt.Fprintf(s.errOut, "error at line %d: %v\n", statement.Line, err)
+			} else {
+				fmt.Fprintf(s.errOut, "error: %v\n", err)
+			}
+			if !s.continueOnError || ctx.Err() != nil {
+				break
+			}
+		}
+	}
+	return ok
+}
+
+//execute executes a statement, writing its rows or the number of rows affected
+func (s *session) execute(ctx context.Context, query string) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if s.interrupt != nil {
+		//discard an interrupt made at the prompt, rather than cancelling this statement
+		select {
+		case <-s.interrupt:
+		default:
+		}
+
+		go func() {
+			select {
+			case <-s.interrupt:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+	}
+
+	word := strings.ToUpper(firstWord(query))
+	if s.plan && planWords[word] {
+		s.writePlan(ctx, query)
+	}
+
+	start := time.Now()
+	var err error
+	if rowWords[word] {
+		err = s.query(ctx, query)
+	} else {
+		err = s.exec(ctx, query, countWords[word])
+	}
+
+	if s.timing {
+		fmt.Fprintf(s.errOut, "Time: %.3f ms\n", float64(time.Since(start))/float64(time.Millisecond))
+	}
+	return err
+}
+
+//exec executes a statement, writing the number of rows affected if count is true
+func (s *session) exec(ctx context.Context, query string, count bool) error {
+	result, err := s.conn.ExecContext(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	if n, err := result.RowsAffected(); err == nil && count {
+		fmt.Fprintf(s.out, "%d %s affected\n", n, plural(n, "row"))
+	} else {
+		fmt.Fprintln(s.out, "OK")
+	}
+	return nil
+}
+
+//query writes each result set of the query
+func (s *session) query(ctx context.Context, query string) error {
+	rows, err := s.conn.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for {
+		columns, err := rows.Columns()
+		if err != nil {
+			return err
+		}
+		if len(columns) == 0 {
+			fmt.Fprintln(s.out, "OK")
+			return nil
+		}
+
+		if err := writeRows(s.out, rows, s.format); err != nil {
+			return err
+		}
+		if !rows.NextResultSet() {
+			break
+		}
+	}
+	return rows.Err()
+}
+
+func (s *session) writePlan(ctx context.Context, query string) {
+	plan, err := sqlanywhere.Explain(ctx, s.conn, query, sqlanywhere.ExplainShort)
+	if err != nil {
+		fmt.Fprintf(s.errOut, "warning: no plan: %v\n", err)
+		return
+	}
+	fmt.Fprintf(s.errOut, "Plan: %s\n", plan.Text)
+}
+
+//firstWord returns the first word of the statement, after any comments and parentheses
+func firstWord(query string) string {
+	for {
+		query = strings.TrimLeft(query, " \t\r\n(")
+		switch {
+		case strings.HasPrefix(query, "--"), strings.HasPrefix(query, "//"):
+			if i := strings.IndexByte(query, '\n'); i >= 0 {
+				query = query[i+1:]
+				continue
+			}
+			return ""
+		case strings.HasPrefix(query, "/*"):
+			if i := strings.Index(query, "*/"); i >= 0 {
+				query = query[i+2:]
+				continue
+			}
+			return ""
+		}
+
+		end := strings.IndexFunc(query, func(r rune) bool {
+			return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '_')
+		})
+		if end < 0 {
+			return query
+		}
+		return query[:end]
+	}
+}
+
+func plural(n int64, word string) string {
+	if n == 1 {
+		return word
+	}
+	return word + "s"
+}
+
+//repl reads statements from in, executing each once complete, and commands starting with a backslash
+func (s *session) repl(ctx context.Context, in io.Reader) error {
+	s.interrupt = make(chan os.Signal, 1)
+	signal.Notify(s.interrupt, os.Interrupt)
+	defer signal.Stop(s.interrupt)
+
+	fmt.Fprintln(s.out, `Enter statements ending with ; or a "go" line, \? for help, \q to quit.`)
+
+	lines := newLineReader(in)
+	var buffer strings.Builder
+	for {
+		if buffer.Len() == 0 {
+			fmt.Fprint(s.out, "saquery> ")
+		} else {
+			fmt.Fprint(s.out, "      -> ")
+		}
+
+		line, err := lines.read()
+		if err == io.EOF {
+			fmt.Fprintln(s.out)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if buffer.Len() == 0 {
+			if trimmed == "" {
+				continue
+			}
+			if strings.HasPrefix(trimmed, `\`) {
+				if quit := s.command(ctx, trimmed); quit {
+					return nil
+				}
+				continue
+			}
+		}
+
+		buffer.WriteString(line)
+		buffer.WriteByte('\n')
+		if !sqlsplit.Complete(buffer.String(), s.delimiter) {
+			continue
+		}
+
+		script := strings.TrimSpace(buffer.String())
+		buffer.Reset()
+		s.history.add(script)
+		s.execScript(ctx, script)
+	}
+}
+
+const help = `Commands:
+  \d [name]        list tables and views, or describe a table, view or procedure
+  \g [n]           execute the last statement again, or statement n of the history
+  \o [format]      show or set the output format: table, csv or json
+  \plan [on|off]   toggle showing the plan of each query
+  \s               show the history
+  \timing [on|off] toggle showing the duration of each statement
+  \q               quit
+`
+
+//command executes a command, returning true to quit
+func (s *session) command(ctx context.Context, line string) bool {
+	fields := strings.Fields(line)
+	name, args := fields[0], fields[1:]
+
+	switch name {
+	case `\q`, `\quit`:
+		return true
+
+	case `\?`, `\h`, `\help`:
+		fmt.Fprint(s.out, help)
+
+	case `\timing`:
+		s.timing = toggle(s.timing, args)
+		fmt.Fprintf(s.out, "Timing is %s.\n", onOff(s.timing))
+
+	case `\plan`:
+		s.plan = toggle(s.plan, args)
+		fmt.Fprintf(s.out, "Plans are %s.\n", onOff(s.plan))
+
+	case `\o`:
+		if len(args) == 0 {
+			fmt.Fprintf(s.out, "Output format is %s.\n", s.format)
+		} else if validFormat(args[0]) {
+			s.format = args[0]
+		} else {
+			fmt.Fprintf(s.errOut, "error: unknown output format %q\n", args[0])
+		}
+
+	case `\d`:
+		if err := s.describe(ctx, strings.Join(args, " ")); err != nil {
+			fmt.Fprintf(s.errOut, "error: %v\n", err)
+		}
+
+	case `\s`:
+		for i, entry := range s.history.entries {
+			fmt.Fprintf(s.out, "%4d  %s\n", i+1, strings.Replace(entry, "\n", "\n      ", -1))
+		}
+
+	case `\g`:
+		entry, err := s.history.get(args)
+		if err != nil {
+			fmt.Fprintf(s.errOut, "error: %v\n", err)
+			break
+		}
+		fmt.Fprintln(s.out, entry)
+		s.history.add(entry)
+		s.execScript(ctx, entry)
+
+	default:
+		fmt.Fprintf(s.errOut, "error: unknown command %s, \\? for help\n", name)
+	}
+	return false
+}
+
+//toggle returns the setting given by args, on or off, or the opposite of current without args
+func toggle(current bool, args []string) bool {
+	if len(args) == 0 {
+		return !current
+	}
+	return strings.EqualFold(args[0], "on")
+}
+
+func onOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
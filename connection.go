@@ -7,26 +7,76 @@ import (
 	"context"
 	"database/sql"
 	"database/sql/driver"
-	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 	"unsafe"
 )
 
 //connection implements driver.Conn
 type connection struct {
-	ptr    *C.a_sqlany_connection
-	errbuf [C.SACAPI_ERROR_SIZE]byte
-	valid  bool
+	ptr       *C.a_sqlany_connection
+	errbuf    [C.SACAPI_ERROR_SIZE]byte
+	valid     bool
+	connector *connector
+
+	//mu guards idle, serialising health checks with the pool handing out the connection
+	mu   sync.Mutex
+	idle bool
+
+	//tx is the outstanding transaction, if any
+	tx *tx
+
+	//autocommit is true when each statement is committed as it executes
+	autocommit bool
+
+	//isolation is the current isolation level, and defaultIsolation the level outside of transactions.
+	//Both are empty until known.
+	isolation        driverIsolationLevel
+	defaultIsolation driverIsolationLevel
+
+	//requestTimeout and queryTimeout are the server timeouts in seconds currently set from a context deadline
+	requestTimeout int
+	queryTimeout   int
+
+	//handle identifies the connection to the client library callbacks, zero if none are registered.
+	//waitRegistered is true if the wait callback is registered.
+	handle         uintptr
+	waitRegistered bool
+
+	//waitCtx is the context of the request in progress, checked by the wait callback
+	waitCtx       context.Context
+	waitCancelled bool
+
+	//cache holds prepared statements for reuse, nil if disabled
+	cache *stmtCache
+
+	//options holds the original values of temporary options changed by the driver, restored in ResetSession
+	options map[string]string
 }
 
+//IsValid is called by the pool before the connection is returned to it, implementing driver.Validator.
+//The connection is idle until the pool hands it out again via ResetSession.
 func (con *connection) IsValid() bool {
+	con.mu.Lock()
+	defer con.mu.Unlock()
+
+	con.idle = true
+	return con.isValid()
+}
+
+func (con *connection) isValid() bool {
 	return con.valid && con.ptr != nil
 }
 
 func (con *connection) connect(ctx context.Context, name string) error {
-	err := con.awaitFunc(ctx, func() error {
+	//the wait callback is registered once connected, so connecting is cancelled from a goroutine
+	err := con.awaitGoroutine(ctx, func() error {
 		str := C.CString(name)
 		defer C.free(unsafe.Pointer(str))
 
@@ -37,6 +87,34 @@ func (con *connection) connect(ctx context.Context, name string) error {
 			return err
 		}
 
+		if err := con.setAutocommit(con.autocommitDefault()); err != nil {
+			C.sqlany_disconnect(con.ptr)
+			C.sqlany_free_connection(con.ptr)
+			con.valid = false
+			return err
+		}
+
+		if err := con.initIsolation(); err != nil {
+			C.sqlany_disconnect(con.ptr)
+			C.sqlany_free_connection(con.ptr)
+			con.valid = false
+			return err
+		}
+
+		if err := con.initStatementCache(); err != nil {
+			C.sqlany_disconnect(con.ptr)
+			C.sqlany_free_connection(con.ptr)
+			con.valid = false
+			return err
+		}
+
+		if err := con.registerCallbacks(); err != nil {
+			C.sqlany_disconnect(con.ptr)
+			C.sqlany_free_connection(con.ptr)
+			con.valid = false
+			return err
+		}
+
 		con.valid = true
 		return nil
 	})
@@ -44,10 +122,32 @@ func (con *connection) connect(ctx context.Context, name string) error {
 	return err
 }
 
+//Ping makes a round trip to the server, implementing driver.Pinger.
+//The connection is marked invalid if the server cannot be reached.
 func (con *connection) Ping(ctx context.Context) error {
-	if !con.IsValid() {
+	if !con.isValid() {
+		return driver.ErrBadConn
+	}
+
+	err := con.awaitFunc(ctx, con.ping)
+	if isConnectionError(err) {
+		con.valid = false
 		return driver.ErrBadConn
 	}
+	return err
+}
+
+//ping executes a trivial query, discarding the result
+func (con *connection) ping() error {
+	str := C.CString("SELECT 1")
+	defer C.free(unsafe.Pointer(str))
+
+	stmt := C.sqlany_execute_direct(con.ptr, str)
+	if stmt == nil {
+		return con.lasterr("did not ping")
+	}
+	C.sqlany_free_stmt(stmt)
+
 	return nil
 }
 
@@ -61,6 +161,9 @@ func (con *connection) lasterr(prefix string) error {
 	if code == DriverErrorCodeEOF {
 		return io.EOF
 	}
+	if code < 0 {
+		stats.countError(int(code))
+	}
 
 	if pos := bytes.IndexByte(buf, 0); pos >= 0 {
 		buf = buf[:pos]
@@ -69,69 +172,142 @@ func (con *connection) lasterr(prefix string) error {
 }
 
 func (con *connection) Close() error {
+	trace := con.startTrace(nil, OpClose, "", 0)
+
+	if con.cache != nil {
+		con.cache.clear()
+	}
+
+	//invalidate the connection before freeing it, so the health checker does not ping a freed handle
+	con.mu.Lock()
+	con.valid = false
+
 	var err error
 	if C.sqlany_disconnect(con.ptr) != 0 { //any uncommitted txns rolled back.
 		err = con.lasterr("disconnect")
 	}
 	C.sqlany_free_connection(con.ptr)
+	con.ptr = nil
+	con.mu.Unlock()
 
-	con.valid = false
+	con.unregisterCallbacks()
+
+	if con.connector != nil {
+		con.connector.untrack(con)
+	}
 
 	sacapi.connectionClosed()
 
+	trace.end(0, err)
 	return err
 }
 
 // ResetSession is called prior to executing a query on the connection
 // if the connection has been used before. If the driver returns ErrBadConn
 // the connection is discarded.
+//
+// Any outstanding transaction is rolled back, temporary options changed by the driver
+// are restored and the connector's reset SQL, if any, is executed.
 func (con *connection) ResetSession(ctx context.Context) error {
 	if con == nil {
 		return driver.ErrBadConn
 	}
 
+	con.mu.Lock()
+	defer con.mu.Unlock()
+
+	con.idle = false
+
 	if !con.valid {
 		return driver.ErrBadConn
 	}
 
+	if err := con.awaitFunc(ctx, con.reset); err != nil {
+		con.valid = false
+		return driver.ErrBadConn
+	}
+
 	return nil
 }
 
-//cancel cancels an outstanding request on the connection
-func (con *connection) cancel() {
-	C.sqlany_cancel(con.ptr)
-}
+//reset returns the connection to the state it had when it was first connected
+func (con *connection) reset() error {
+	if con.tx != nil {
+		if C.sqlany_rollback(con.ptr) == 0 {
+			return con.lasterr("did not rollback outstanding transaction")
+		}
+		con.tx.state = txAborted
+		con.tx = nil
 
-//awaitFunc runs a function with opportunity to cancel via the given context
-func (con *connection) awaitFunc(ctx context.Context, run func() error) error {
-	// avoid spawning a goroutine if the context cannot be cancelled.
-	if ctx.Done() == nil {
-		return run()
+		if err := con.setAutocommit(con.autocommitDefault()); err != nil {
+			return err
+		}
 	}
 
-	if ctx.Err() != nil {
-		return ctx.Err()
+	if err := con.restoreOptions(); err != nil {
+		return err
 	}
+	con.requestTimeout = 0
 
-	done := make(chan error)
+	if err := con.restoreIsolation(); err != nil {
+		return err
+	}
 
-	go func() {
-		done <- run()
-	}()
+	if con.connector != nil && con.connector.resetSQL != "" {
+		if err := con.execImmediate(con.connector.resetSQL); err != nil {
+			return err
+		}
+	}
 
-	select {
-	case err := <-done:
-		return err
+	return nil
+}
+
+//setTemporaryOption sets a temporary option for the connection, remembering its original value
+//so it can be restored by restoreOption or ResetSession.
+func (con *connection) setTemporaryOption(name, value string) error {
+	if _, changed := con.options[name]; !changed {
+		original, err := con.option(name)
+		if err != nil {
+			return err
+		}
+		if con.options == nil {
+			con.options = make(map[string]string)
+		}
+		con.options[name] = original
+	}
+
+	return con.execImmediate(fmt.Sprintf("SET TEMPORARY OPTION %s = '%s'", name, value))
+}
+
+//restoreOption restores a temporary option changed by setTemporaryOption to its original value
+func (con *connection) restoreOption(name string) error {
+	original, changed := con.options[name]
+	if !changed {
+		return nil
+	}
+
+	if err := con.execImmediate(fmt.Sprintf("SET TEMPORARY OPTION %s = '%s'", name, original)); err != nil {
+		return fmt.Errorf("did not restore option %s: %v", name, err)
+	}
 
-	case <-ctx.Done():
-		//context finished first; send cancellation to interrupt 'run' function
-		con.cancel()
+	delete(con.options, name)
+	return nil
+}
 
-		//wait for interrupted run to finish, but discard it's error
-		discardErr := <-done
-		_ = discardErr
-		return ctx.Err()
+//restoreOptions restores all temporary options changed by setTemporaryOption
+func (con *connection) restoreOptions() error {
+	for name := range con.options {
+		if err := con.restoreOption(name); err != nil {
+			return err
+		}
 	}
+	return nil
+}
+
+//cancel cancels an outstanding request on the connection
+func (con *connection) cancel() {
+	C.sqlany_cancel(con.ptr)
+	atomic.AddUint64(&stats.cancellations, 1)
 }
 
 //execImmediate executes a SQL statement with no arguments and no results.
@@ -145,29 +321,73 @@ func (con *connection) execImmediate(sql string) error {
 	return nil
 }
 
-func (con *connection) execImmediateContext(ctx context.Context, sql string) error {
-	return con.awaitFunc(ctx, func() error {
-		return con.execImmediate(sql)
-	})
+func (con *connection) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	slow := con.startSlowQueryCheck(ctx)
+	result, err := con.execContext(ctx, query, args)
+	slow.end(query, args, err)
+	return result, err
 }
 
-func (con *connection) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+func (con *connection) execContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
 	if len(args) > 0 {
-		stmt, err := con.PrepareContext(ctx, query)
+		stmt, err := con.prepareCached(ctx, query)
 		if err != nil {
-			stmt.Close()
 			return nil, err
 		}
-		result, err := stmt.(*statement).ExecContext(ctx, query, args)
-		stmt.Close() //ensure statement is freed
+		result, err := stmt.ExecContext(ctx, query, args)
+		stmt.release(err) //ensure statement is freed, unless cached
 		return result, err
 	}
 
-	err := con.execImmediateContext(ctx, query)
+	affected, err := con.execAffectedContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
-	return &result{con: con}, nil
+	return &result{con: con, rowsAffected: affected}, nil
+}
+
+//execAffected executes a SQL statement with no arguments, returning the number of rows affected.
+func (con *connection) execAffected(query string) (int64, error) {
+	str := C.CString(query)
+	defer C.free(unsafe.Pointer(str))
+
+	stmt := C.sqlany_execute_direct(con.ptr, str)
+	if stmt == nil {
+		return 0, con.lasterr("did not execute")
+	}
+	defer C.sqlany_free_stmt(stmt)
+
+	return affectedRows(stmt), nil
+}
+
+func (con *connection) execAffectedContext(ctx context.Context, query string) (int64, error) {
+	if err := con.setRequestTimeout(ctx); err != nil {
+		return 0, err
+	}
+
+	trace := con.startTrace(ctx, OpExec, query, 0)
+	start := time.Now()
+
+	var affected int64
+	err := con.awaitFunc(ctx, func() error {
+		var err error
+		affected, err = con.execAffected(query)
+		return err
+	})
+
+	stats.execLatency.observe(time.Since(start))
+	trace.end(affected, err)
+	return affected, err
+}
+
+//affectedRows returns the number of rows affected by the last execution of the statement.
+//Statements that do not affect rows, such as DDL, report none.
+func affectedRows(stmt *C.a_sqlany_stmt) int64 {
+	n := int64(C.sqlany_affected_rows(stmt))
+	if n < 0 {
+		return 0
+	}
+	return n
 }
 
 //execDirect executes a SQL statement with no arguments, returning a statement to access results, if any.
@@ -179,10 +399,18 @@ func (con *connection) execDirect(query string) (*statement, error) {
 	if ptr == nil {
 		return nil, con.lasterr("did not execute direct")
 	}
-	return &statement{con: con, ptr: ptr, closeStatementOnRowsClose: true}, nil
+	atomic.AddInt64(&stats.statements, 1)
+	return &statement{con: con, ptr: ptr, query: query, closeStatementOnRowsClose: true}, nil
 }
 
 func (con *connection) execDirectContext(ctx context.Context, query string) (*statement, error) {
+	if err := con.setRequestTimeout(ctx); err != nil {
+		return nil, err
+	}
+
+	trace := con.startTrace(ctx, OpQuery, query, 0)
+	start := time.Now()
+
 	var stmt *statement
 
 	err := con.awaitFunc(ctx, func() error {
@@ -191,17 +419,31 @@ func (con *connection) execDirectContext(ctx context.Context, query string) (*st
 		return err
 	})
 
+	stats.queryLatency.observe(time.Since(start))
+	trace.end(0, err)
 	return stmt, err
 }
 
 func (con *connection) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	slow := con.startSlowQueryCheck(ctx)
+	rows, err := con.queryContext(ctx, query, args)
+	slow.end(query, args, err)
+	return rows, err
+}
+
+func (con *connection) queryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
 	if len(args) > 0 {
-		stmt, err := con.PrepareContext(ctx, query)
+		stmt, err := con.prepareCached(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		stmt.closeStatementOnRowsClose = true
+		rows, err := stmt.QueryContext(ctx, args)
 		if err != nil {
+			stmt.release(err)
 			return nil, err
 		}
-		stmt.(*statement).closeStatementOnRowsClose = true
-		return stmt.(*statement).QueryContext(ctx, args)
+		return rows, nil
 	}
 	stmt, err := con.execDirectContext(ctx, query)
 	if err != nil {
@@ -227,16 +469,28 @@ func (con *connection) prepare(query string) (*statement, error) {
 	if ptr == nil {
 		return nil, con.lasterr("did not prepare statement")
 	}
-	s := &statement{con: con, ptr: ptr, closeStatementOnRowsClose: false, args: args}
+	atomic.AddInt64(&stats.statements, 1)
+	s := &statement{con: con, ptr: ptr, query: query, closeStatementOnRowsClose: false, args: args}
+
+	if err := s.describeParams(); err != nil {
+		s.Close()
+		return nil, err
+	}
 
 	return s, nil
 }
 
 func (con *connection) Prepare(query string) (driver.Stmt, error) {
-	return con.prepare(query)
+	return con.prepareContext(context.Background(), query)
 }
 
 func (con *connection) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	return con.prepareContext(ctx, query)
+}
+
+func (con *connection) prepareContext(ctx context.Context, query string) (*statement, error) {
+	trace := con.startTrace(ctx, OpPrepare, query, 0)
+
 	var stmt *statement
 
 	err := con.awaitFunc(ctx, func() error {
@@ -245,6 +499,7 @@ func (con *connection) PrepareContext(ctx context.Context, query string) (driver
 		return err
 	})
 
+	trace.end(0, err)
 	return stmt, err
 }
 
@@ -298,47 +553,190 @@ func (con *connection) Begin() (driver.Tx, error) {
 // This must also check opts.ReadOnly to determine if the read-only
 // value is true to either set the read-only transaction property if supported
 // or return an error if it is not supported.
+//
+// A read only transaction without an isolation level uses readonly-statement-snapshot isolation
+// if the database allows snapshot isolation, so the server rejects each statement that writes.
+// Otherwise, or with another isolation level, read only is checked only at commit: Commit rolls
+// back any writes and returns ErrReadOnlyTransaction.
+//
+// The default isolation level leaves the connection's isolation level unchanged.
 func (con *connection) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
-	if opts.ReadOnly {
-		return nil, fmt.Errorf("unsupported transaction option: read only")
+	trace := con.startTrace(ctx, OpBegin, "", 0)
+
+	tx, err := con.beginTx(ctx, opts)
+
+	trace.end(0, err)
+	return tx, err
+}
+
+func (con *connection) beginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	level := sql.IsolationLevel(opts.Isolation)
+
+	//the server rejects writes under readonly-statement-snapshot isolation; without snapshot
+	//isolation a read only transaction keeps the connection's level, and writes are detected at commit
+	if opts.ReadOnly && level == sql.LevelDefault {
+		switch err := con.checkSnapshotAllowed(); err {
+		case nil:
+			level = LevelReadOnlyStatementSnapshot
+		case ErrSnapshotIsolationDisabled:
+		default:
+			return nil, err
+		}
 	}
 
-	//BEGIN TRANSACTION is only required if auto commit was turned on.
-	//Otherwise, transactions are started implicitly.
-	if err := con.execImmediate("BEGIN TRANSACTION"); err != nil {
+	var isolation driverIsolationLevel
+	if level != sql.LevelDefault {
+		var err error
+		if isolation, err = con.isolationLevel(level); err != nil {
+			return nil, err
+		}
+
+		//remember the level to restore when the transaction ends
+		if _, err := con.currentIsolation(); err != nil {
+			return nil, err
+		}
+	}
+
+	//transactions start implicitly once autocommit is off, and it is turned back on by Commit or Rollback
+	if err := con.setAutocommit(false); err != nil {
 		return nil, err
 	}
 
-	lastIsolation, err := con.setIsolation(opts.Isolation)
+	if isolation != "" {
+		if err := con.applyIsolation(isolation); err != nil {
+			if restoreErr := con.setAutocommit(con.autocommitDefault()); restoreErr != nil {
+				con.valid = false
+				return nil, fmt.Errorf("%w; did not restore autocommit: %v", err, restoreErr)
+			}
+			return nil, err
+		}
+	}
+
+	con.tx = &tx{con: con, opts: opts, ctx: ctx}
+	return con.tx, nil
+}
+
+//checkSnapshotAllowed returns ErrSnapshotIsolationDisabled unless the database allows snapshot isolation
+func (con *connection) checkSnapshotAllowed() error {
+	allowed, err := con.option("allow_snapshot_isolation")
 	if err != nil {
-		return nil, err
+		return err
+	}
+
+	if !strings.EqualFold(allowed, "on") {
+		return ErrSnapshotIsolationDisabled
+	}
+	return nil
+}
+
+//setAutocommit turns autocommit on or off for the connection
+func (con *connection) setAutocommit(on bool) error {
+	var mode C.sacapi_bool
+	if on {
+		mode = 1
+	}
+
+	if C.sqlany_set_autocommit(con.ptr, mode) == 0 {
+		return con.lasterr("did not set autocommit")
 	}
 
-	return &tx{con: con, opts: opts, lastIsolation: *lastIsolation}, nil
+	con.autocommit = on
+	return nil
 }
 
-//setIsolation sets the current connection isolation level
-func (con *connection) setIsolation(level driver.IsolationLevel) (*driverIsolationLevel, error) {
+//autocommitDefault is the autocommit mode outside of transactions; on unless the connector is in chained mode
+func (con *connection) autocommitDefault() bool {
+	return con.connector == nil || !con.connector.chained
+}
 
-	isolation, supported := isolationLevels[sql.IsolationLevel(level)]
+//isolationLevel returns the sqlanywhere isolation level for level, if supported by the database
+func (con *connection) isolationLevel(level sql.IsolationLevel) (driverIsolationLevel, error) {
+	isolation, supported := isolationLevels[level]
 	if !supported {
-		return nil, fmt.Errorf("unsupported transaction option: isolation level: %v", level)
+		return "", fmt.Errorf("unsupported transaction option: isolation level: %v", level)
 	}
 
-	previous, err := con.option("isolation_level")
+	if isolation.isSnapshot() {
+		if err := con.checkSnapshotAllowed(); err != nil {
+			return "", err
+		}
+	}
+
+	return isolation, nil
+}
+
+//currentIsolation returns the connection's isolation level, querying the server only the first time.
+//A level first seen outside of a transaction is the connection's default level, restored after transactions.
+func (con *connection) currentIsolation() (driverIsolationLevel, error) {
+	if con.isolation != "" {
+		return con.isolation, nil
+	}
+
+	level, err := con.option("isolation_level")
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
-	if err := con.execImmediate("SET TEMPORARY OPTION isolation_level = " + string(isolation)); err != nil {
-		return nil, err
+	con.isolation = driverIsolationLevel(level)
+	if con.defaultIsolation == "" {
+		con.defaultIsolation = con.isolation
+	}
+	return con.isolation, nil
+}
+
+//applyIsolation sets the connection isolation level, unless it is already set
+func (con *connection) applyIsolation(isolation driverIsolationLevel) error {
+	if isolation == con.isolation {
+		return nil
 	}
 
-	was := driverIsolationLevel(previous)
-	return &was, nil
+	//snapshot levels have no number, so are set as an option
+	if n, err := strconv.Atoi(string(isolation)); err == nil {
+		if C.sqlany_set_transaction_isolation(con.ptr, C.sacapi_u32(n)) == 0 {
+			return con.lasterr("did not set isolation level")
+		}
+	} else if err := con.execImmediate("SET TEMPORARY OPTION isolation_level = '" + string(isolation) + "'"); err != nil {
+		return err
+	}
+
+	con.isolation = isolation
+	return nil
+}
+
+//restoreIsolation restores the connection's default isolation level, if it was changed
+func (con *connection) restoreIsolation() error {
+	if con.isolation == con.defaultIsolation {
+		return nil
+	}
+
+	if err := con.applyIsolation(con.defaultIsolation); err != nil {
+		return fmt.Errorf("did not restore isolation level: %v", err)
+	}
+	return nil
+}
+
+//initIsolation applies the connector's default isolation level, if set, to a new connection
+func (con *connection) initIsolation() error {
+	if con.connector == nil || con.connector.defaultIsolation == sql.LevelDefault {
+		return nil
+	}
+
+	isolation, err := con.isolationLevel(con.connector.defaultIsolation)
+	if err != nil {
+		return err
+	}
+
+	//the server's level is unknown, so set it unconditionally
+	con.isolation = ""
+	if err := con.applyIsolation(isolation); err != nil {
+		return err
+	}
+
+	con.defaultIsolation = isolation
+	return nil
 }
 
-//queryInt fetches an int64 directly
+//queryInt fetches an integer directly, the first column of the first row
 func (con *connection) queryInt(query string) (int64, error) {
 	if con == nil || con.ptr == nil {
 		return -1, errors.New("connection is nil")
@@ -362,8 +760,39 @@ func (con *connection) queryInt(query string) (int64, error) {
 		return -1, con.lasterr("did not get value")
 	}
 
-	buf := C.GoBytes(unsafe.Pointer(value.buffer), C.int(*value.length))
-	id := int64(binary.LittleEndian.Uint32(buf))
+	if *value.is_null != 0 {
+		return 0, nil
+	}
+
+	return dataValueInt(value)
+}
 
-	return id, nil
+//dataValueInt decodes an integer value of any size
+func dataValueInt(value *C.a_sqlany_data_value) (int64, error) {
+	buf := unsafe.Pointer(value.buffer)
+
+	switch value._type {
+	case C.A_VAL64:
+		return *(*int64)(buf), nil
+	case C.A_UVAL64:
+		return int64(*(*uint64)(buf)), nil
+	case C.A_VAL32:
+		return int64(*(*int32)(buf)), nil
+	case C.A_UVAL32:
+		return int64(*(*uint32)(buf)), nil
+	case C.A_VAL16:
+		return int64(*(*int16)(buf)), nil
+	case C.A_UVAL16:
+		return int64(*(*uint16)(buf)), nil
+	case C.A_VAL8:
+		return int64(*(*int8)(buf)), nil
+	case C.A_UVAL8:
+		return int64(*(*uint8)(buf)), nil
+	case C.A_DOUBLE:
+		return int64(*(*float64)(buf)), nil
+	case C.A_STRING:
+		return strconv.ParseInt(C.GoStringN(value.buffer, C.int(*value.length)), 10, 64)
+	}
+
+	return -1, fmt.Errorf("value of type %v is not an integer", value._type)
 }
@@ -2,5 +2,5 @@
 #include <stdio.h>
 #include <stdlib.h>
 
-#define _SACAPI_VERSION 2
+#define _SACAPI_VERSION 6
 #include <sacapi.h>
\ No newline at end of file
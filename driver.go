@@ -10,6 +10,8 @@ import (
 	"database/sql/driver"
 	"fmt"
 	"sync"
+	"sync/atomic"
+	"time"
 	"unsafe"
 )
 
@@ -46,6 +48,10 @@ func (d *Driver) Open(name string) (driver.Conn, error) {
 
 //OpenConnector returns a new connector, initialising the driver api interface if necessary
 func (d *Driver) OpenConnector(name string) (driver.Connector, error) {
+	return d.openConnector(name)
+}
+
+func (d *Driver) openConnector(name string) (*connector, error) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
@@ -62,7 +68,7 @@ func (d *Driver) OpenConnector(name string) (driver.Connector, error) {
 		}
 	}
 
-	return &connector{name: name}, nil
+	return &connector{name: name, pingTimeout: defaultPingTimeout}, nil
 }
 
 func (d *Driver) connectionClosed() {
@@ -70,6 +76,7 @@ func (d *Driver) connectionClosed() {
 	defer d.mu.Unlock()
 
 	d.connections--
+	atomic.AddInt64(&stats.connections, -1)
 
 	if d.connections == 0 {
 		C.sqlany_fini_ex(d.ptr)
@@ -79,6 +86,48 @@ func (d *Driver) connectionClosed() {
 
 type connector struct {
 	name string
+
+	//pingTimeout bounds each ping made by the health checker
+	pingTimeout time.Duration
+
+	//healthCheckInterval is the period between pings of idle connections, zero if disabled
+	healthCheckInterval time.Duration
+
+	//resetSQL is executed when a pooled connection is reused
+	resetSQL string
+
+	//chained is true if statements outside of transactions are not committed automatically
+	chained bool
+
+	//cancelStrategy is how requests are interrupted when their context finishes
+	cancelStrategy CancelStrategy
+
+	//defaultIsolation is applied to new connections, unless it is sql.LevelDefault
+	defaultIsolation sql.IsolationLevel
+
+	//tracer observes driver operations, nil if disabled
+	tracer Tracer
+
+	//slowQueryThreshold is the duration of statements reported to slowQueryReport, zero if disabled.
+	//The plan is added to the report if slowQueryPlan is true, taking at most slowQueryPlanTimeout.
+	slowQueryThreshold   time.Duration
+	slowQueryReport      func(SlowQuery)
+	slowQueryPlan        bool
+	slowQueryPlanMode    ExplainMode
+	slowQueryPlanTimeout time.Duration
+
+	//statementCacheSize is the number of prepared statements cached per connection, zero if disabled
+	statementCacheSize int
+
+	//fileTransferPolicy permits client file transfers requested by procedures, nil to deny them
+	fileTransferPolicy FileTransferPolicy
+
+	//messageHandler receives messages sent to the client by the server, nil to ignore them
+	messageHandler func(Message)
+
+	mu          sync.Mutex
+	connections map[*connection]struct{}
+	done        chan struct{}
 }
 
 func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
@@ -89,12 +138,16 @@ func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
 	if ptr == nil {
 		return nil, fmt.Errorf("did not create a new connection")
 	}
-	con := &connection{ptr: ptr}
+	con := &connection{ptr: ptr, connector: c}
 
+	trace := con.startTrace(ctx, OpConnect, "", 0)
 	err := con.connect(ctx, c.name)
+	trace.end(0, err)
 
 	if err == nil {
 		sacapi.connections++
+		atomic.AddInt64(&stats.connections, 1)
+		c.track(con)
 	}
 
 	return con, err
@@ -103,3 +156,15 @@ func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
 func (c *connector) Driver() driver.Driver {
 	return sacapi
 }
+
+//Close stops the health checker, if running. It is called by sql.DB.Close.
+func (c *connector) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.done != nil {
+		close(c.done)
+		c.done = nil
+	}
+	return nil
+}
@@ -5,6 +5,13 @@ import "fmt"
 //DriverErrorCodeEOF is the error code representing end of results
 const DriverErrorCodeEOF = 100
 
+//Error codes returned when the connection to the server is lost
+const (
+	DriverErrorCodeCommunication        = -85
+	DriverErrorCodeNotConnected         = -101
+	DriverErrorCodeConnectionTerminated = -308
+)
+
 //DriverError is an error returned by calls to a connection
 type DriverError struct {
 	prefix  string
@@ -15,3 +22,17 @@ type DriverError struct {
 func (err *DriverError) Error() string {
 	return fmt.Sprintf("%s: %s %d", err.prefix, err.message, err.code)
 }
+
+//isConnectionError reports whether err shows the connection to the server is no longer usable
+func isConnectionError(err error) bool {
+	de, ok := err.(*DriverError)
+	if !ok {
+		return false
+	}
+
+	switch de.code {
+	case DriverErrorCodeCommunication, DriverErrorCodeNotConnected, DriverErrorCodeConnectionTerminated:
+		return true
+	}
+	return false
+}
@@ -37,6 +37,9 @@ func TestTemporaryDatabase(t *testing.T) {
 	t.Run("lastInsertId", func(t *testing.T) {
 		testLastInsertID(pool, t)
 	})
+	t.Run("bigLastInsertId", func(t *testing.T) {
+		testBigLastInsertID(pool, t)
+	})
 }
 
 func testBadConnectionString(testdb *TestDatabase) {
@@ -54,7 +57,7 @@ type TestDatabase struct {
 	utility  *sql.DB
 	name     string
 	filename string
-	t        *testing.T
+	t        testing.TB
 }
 
 func (test *TestDatabase) ConnectionString() string {
@@ -144,7 +147,7 @@ func (test *TestDatabase) Cleanup() {
 
 //NewTestDB creates a new random test database. It should be cleaned up with Cleanup() on exit.
 //Creating a database is relatively slow, about 2.5 seconds, so reuse them for faster tests.
-func NewTestDB(t *testing.T) *TestDatabase {
+func NewTestDB(t testing.TB) *TestDatabase {
 
 	name := fmt.Sprintf("sqlany_test_%d", randInt(math.MaxInt64))
 
@@ -0,0 +1,241 @@
+package sqlanywhere
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+//ExplainMode selects the form of an execution plan
+type ExplainMode int
+
+const (
+	//ExplainShort is the brief text plan returned by the PLAN function
+	ExplainShort ExplainMode = iota
+
+	//ExplainLong is the detailed text plan returned by the EXPLANATION function
+	ExplainLong
+
+	//ExplainGraphical is the XML plan returned by the GRAPHICAL_PLAN function, parsed into a tree of operators
+	ExplainGraphical
+)
+
+//planFunctions are the server functions returning each form of plan, given the SQL of a statement
+var planFunctions = map[ExplainMode]string{
+	ExplainShort:     "PLAN",
+	ExplainLong:      "EXPLANATION",
+	ExplainGraphical: "GRAPHICAL_PLAN",
+}
+
+//plan returns the server's plan for query, optimized as if executed now on the connection
+func (con *connection) plan(ctx context.Context, query string, mode ExplainMode) (*Plan, error) {
+	return queryPlan(query, mode, func(planQuery, arg string) (string, error) {
+		return con.queryString(ctx, planQuery, arg)
+	})
+}
+
+//queryPlan returns the server's plan for query, using queryString to run the plan function with the query as its argument
+func queryPlan(query string, mode ExplainMode, queryString func(planQuery, arg string) (string, error)) (*Plan, error) {
+	function, ok := planFunctions[mode]
+	if !ok {
+		return nil, fmt.Errorf("unknown explain mode %d", mode)
+	}
+
+	//the server does not know named parameters, so plan the query with their placeholders
+	query, _ = splitNamed(query)
+
+	text, err := queryString("SELECT "+function+"(?)", query)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{Mode: mode, Text: text}
+	if mode == ExplainGraphical {
+		if plan.Root, err = parsePlan(text); err != nil {
+			return nil, err
+		}
+	}
+	return plan, nil
+}
+
+//queryString returns the first column of the first row of a query with one argument, as a string
+func (con *connection) queryString(ctx context.Context, query string, arg string) (string, error) {
+	stmt, err := con.prepareContext(ctx, query)
+	if err != nil {
+		return "", err
+	}
+	defer stmt.Close()
+
+	r, err := stmt.QueryContext(ctx, []driver.NamedValue{{Ordinal: 1, Value: arg}})
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	dest := make([]driver.Value, 1)
+	if err := r.Next(dest); err != nil {
+		if err == io.EOF {
+			return "", fmt.Errorf("no result for %s", query)
+		}
+		return "", err
+	}
+
+	switch v := dest[0].(type) {
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case nil:
+		return "", nil
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
+
+//RowQueryer queries a single row, implemented by *sql.DB, *sql.Conn and *sql.Tx
+type RowQueryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+//Plan is the server's execution plan for a statement
+type Plan struct {
+	Mode ExplainMode
+
+	//Text is the plan as returned by the server: text, or XML for ExplainGraphical
+	Text string
+
+	//Root is the top operator of the plan, for ExplainGraphical
+	Root *PlanNode
+}
+
+//PlanNode is an element of a graphical plan containing other elements, usually an operator.
+//Elements containing only text are its Properties, keyed by element name. Common properties
+//are extracted into fields where present, and are otherwise zero.
+type PlanNode struct {
+	//Operator is the node's name property, or else its element name
+	Operator string
+
+	Table         string
+	Index         string
+	EstimatedRows float64
+	Cost          float64
+
+	Properties map[string]string
+	Children   []*PlanNode
+}
+
+//planProperties are the property names from which PlanNode fields are extracted, in the form compared by planKey
+var planProperties = struct {
+	operator, table, index, rows, cost []string
+}{
+	operator: []string{"operator", "name"},
+	table:    []string{"table", "tablename", "correlationname"},
+	index:    []string{"index", "indexname"},
+	rows:     []string{"estrows", "estimatedrows", "rowcount", "rowsreturned"},
+	cost:     []string{"estcost", "estimatedcost", "cost", "estruntime", "runtime"},
+}
+
+//Explain returns the server's plan for query, optimized as if executed by db.
+//Named parameters are planned as placeholders.
+func Explain(ctx context.Context, db RowQueryer, query string, mode ExplainMode) (*Plan, error) {
+	return queryPlan(query, mode, func(planQuery, arg string) (string, error) {
+		var text string
+		err := db.QueryRowContext(ctx, planQuery, arg).Scan(&text)
+		return text, err
+	})
+}
+
+//planElement is any XML element of a graphical plan
+type planElement struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr    `xml:",any,attr"`
+	Text     string        `xml:",chardata"`
+	Children []planElement `xml:",any"`
+}
+
+//parsePlan parses the XML of a graphical plan into a tree of nodes
+func parsePlan(text string) (*PlanNode, error) {
+	var root planElement
+	if err := xml.Unmarshal([]byte(text), &root); err != nil {
+		return nil, fmt.Errorf("did not parse graphical plan: %v", err)
+	}
+	return root.node(), nil
+}
+
+//isProperty reports whether the element contains only text
+func (e *planElement) isProperty() bool {
+	return len(e.Children) == 0 && len(e.Attrs) == 0
+}
+
+func (e *planElement) node() *PlanNode {
+	n := &PlanNode{Properties: make(map[string]string)}
+
+	for _, attr := range e.Attrs {
+		n.Properties[attr.Name.Local] = attr.Value
+	}
+
+	for i := range e.Children {
+		child := &e.Children[i]
+		if child.isProperty() {
+			n.Properties[child.XMLName.Local] = strings.TrimSpace(child.Text)
+		} else {
+			n.Children = append(n.Children, child.node())
+		}
+	}
+
+	n.Operator = n.property(planProperties.operator)
+	if n.Operator == "" {
+		n.Operator = e.XMLName.Local
+	}
+	n.Table = n.property(planProperties.table)
+	n.Index = n.property(planProperties.index)
+	n.EstimatedRows = parsePlanNumber(n.property(planProperties.rows))
+	n.Cost = parsePlanNumber(n.property(planProperties.cost))
+
+	return n
+}
+
+//property returns the first of the named properties present, compared by planKey
+func (n *PlanNode) property(names []string) string {
+	for _, name := range names {
+		for key, value := range n.Properties {
+			if planKey(key) == name {
+				return value
+			}
+		}
+	}
+	return ""
+}
+
+//planKey is a property name in lower case without separators, so "EstRows", "est_rows" and "Est. rows" match
+func planKey(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, name)
+}
+
+//parsePlanNumber parses a number from a plan, which may have thousands separators, returning zero if it is not a number
+func parsePlanNumber(s string) float64 {
+	f, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(s), ",", ""), 64)
+	if err != nil {
+		return 0
+	}
+	return f
+}
+
+//Walk calls fn for the node and each of its descendants, depth first
+func (n *PlanNode) Walk(fn func(*PlanNode)) {
+	fn(n)
+	for _, child := range n.Children {
+		child.Walk(fn)
+	}
+}
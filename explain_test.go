@@ -0,0 +1,150 @@
+package sqlanywhere
+
+import (
+	"context"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+//capturePlan saves the graphical plan of TestExplain's primary key lookup, from which TestParsePlanCaptured parses it
+var capturePlan = flag.Bool("capture-plan", false, "save the graphical plan of TestExplain to "+capturedPlanFile)
+
+const capturedPlanFile = "testdata/primary_key_plan.xml"
+
+func TestExplain(t *testing.T) {
+	testdb := NewTestDB(t)
+	defer testdb.Cleanup()
+
+	db, close := testdb.Open()
+	defer close()
+
+	if _, err := db.Exec("create table explain_test (id int primary key, name varchar(20))"); err != nil {
+		t.Fatal(err)
+	}
+
+	const query = "select name from explain_test where id = :id"
+	ctx := context.Background()
+
+	for _, mode := range []ExplainMode{ExplainShort, ExplainLong} {
+		plan, err := Explain(ctx, db, query, mode)
+		if err != nil {
+			t.Fatalf("mode %d: %v", mode, err)
+		}
+		if !strings.Contains(plan.Text, "explain_test") {
+			t.Fatalf("mode %d: want plan of explain_test, got %q", mode, plan.Text)
+		}
+	}
+
+	plan, err := Explain(ctx, db, query, ExplainGraphical)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *capturePlan {
+		if err := os.MkdirAll(filepath.Dir(capturedPlanFile), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(capturedPlanFile, []byte(plan.Text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkPrimaryKeyLookup(t, plan.Root, plan.Text)
+
+	if _, err := Explain(ctx, db, query, ExplainMode(-1)); err == nil {
+		t.Fatal("want error for unknown mode")
+	}
+}
+
+//checkPrimaryKeyLookup checks that a graphical plan of a lookup on explain_test's primary key
+//reads the primary key index, named after the table, and estimates the rows returned
+func checkPrimaryKeyLookup(t *testing.T, root *PlanNode, text string) {
+	t.Helper()
+
+	if root == nil {
+		t.Fatalf("want parsed plan, got %q", text)
+	}
+
+	var index string
+	var rows float64
+	root.Walk(func(n *PlanNode) {
+		if index == "" && strings.EqualFold(n.Index, "explain_test") {
+			index = n.Index
+		}
+		if n.EstimatedRows > rows {
+			rows = n.EstimatedRows
+		}
+	})
+
+	if index == "" {
+		t.Fatalf("want primary key index explain_test in plan:\n%s", text)
+	}
+	if rows <= 0 {
+		t.Fatalf("want estimated rows in plan:\n%s", text)
+	}
+}
+
+//TestParsePlanCaptured parses a plan captured from the server by TestExplain with -capture-plan
+func TestParsePlanCaptured(t *testing.T) {
+	text, err := ioutil.ReadFile(capturedPlanFile)
+	if os.IsNotExist(err) {
+		t.Fatalf("no captured plan: run go test -run TestExplain -capture-plan against a server to save %s", capturedPlanFile)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root, err := parsePlan(string(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkPrimaryKeyLookup(t, root, string(text))
+}
+
+//TestParsePlan checks the mapping of elements to nodes and properties, for an illustrative plan
+//rather than one in the server's format
+func TestParsePlan(t *testing.T) {
+	const text = `<?xml version="1.0"?>
+<plan>
+	<node name="JNL">
+		<EstRows>1,250</EstRows>
+		<est_cost>0.5</est_cost>
+		<node name="IndexScan" table="customer">
+			<Index>pk_customer</Index>
+			<EstRows>1</EstRows>
+		</node>
+		<node name="TableScan">
+			<Table>orders</Table>
+		</node>
+	</node>
+</plan>`
+
+	root, err := parsePlan(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if root.Operator != "plan" || len(root.Children) != 1 {
+		t.Fatalf("want plan root with one operator, got %+v", root)
+	}
+
+	join := root.Children[0]
+	if join.Operator != "JNL" || join.EstimatedRows != 1250 || join.Cost != 0.5 || len(join.Children) != 2 {
+		t.Fatalf("unexpected join node %+v", join)
+	}
+
+	scan := join.Children[0]
+	if scan.Operator != "IndexScan" || scan.Table != "customer" || scan.Index != "pk_customer" || scan.EstimatedRows != 1 {
+		t.Fatalf("unexpected index scan node %+v", scan)
+	}
+
+	var operators []string
+	root.Walk(func(n *PlanNode) {
+		operators = append(operators, n.Operator)
+	})
+	if strings.Join(operators, " ") != "plan JNL IndexScan TableScan" {
+		t.Fatalf("unexpected walk order %v", operators)
+	}
+}
@@ -0,0 +1,115 @@
+package export
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"time"
+)
+
+//CSVOptions configure CSV
+type CSVOptions struct {
+	//Delimiter separates fields. Zero is a comma.
+	Delimiter rune
+
+	//Null is written for NULL values. Empty by default, the same as an empty string.
+	Null string
+
+	//NoHeader omits the header of column names
+	NoHeader bool
+
+	//LF ends records with \n rather than \r\n as RFC 4180 specifies
+	LF bool
+
+	//Binary is the encoding of binary values
+	Binary BinaryEncoding
+}
+
+//CSV writes the rows to w as RFC 4180 CSV, after a header of the column names. opts may be nil.
+//Numbers and DECIMAL values are written in full, BIT as 1 or 0, and times with DateLayout, TimeLayout
+//or TimestampLayout. It returns the number of rows written, which are written to w even if a later row fails.
+//The rows are not closed.
+func CSV(w io.Writer, rows *sql.Rows, opts *CSVOptions) (n int64, err error) {
+	if opts == nil {
+		opts = &CSVOptions{}
+	}
+
+	cols, err := columns(rows)
+	if err != nil {
+		return 0, err
+	}
+
+	writer := csv.NewWriter(w)
+	if opts.Delimiter != 0 {
+		writer.Comma = opts.Delimiter
+	}
+	writer.UseCRLF = !opts.LF
+
+	defer func() {
+		writer.Flush()
+		if flushErr := writer.Error(); err == nil {
+			err = flushErr
+		}
+	}()
+
+	record := make([]string, len(cols))
+	if !opts.NoHeader {
+		for i, c := range cols {
+			record[i] = c.name
+		}
+		if err := writer.Write(record); err != nil {
+			return 0, fmt.Errorf("did not write header: %v", err)
+		}
+	}
+
+	s := newScanner(len(cols))
+	for rows.Next() {
+		if err := s.scan(rows); err != nil {
+			return n, err
+		}
+		for i, value := range s.values {
+			record[i] = csvField(cols[i], value, opts)
+		}
+		if err := writer.Write(record); err != nil {
+			return n, fmt.Errorf("did not write row: %v", err)
+		}
+		n++
+	}
+	return n, rows.Err()
+}
+
+//QueryCSV writes the rows of the query to w as CSV; see CSV
+func QueryCSV(ctx context.Context, q Querier, w io.Writer, opts *CSVOptions, query string, args ...interface{}) (int64, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	return CSV(w, rows, opts)
+}
+
+func csvField(c column, value interface{}, opts *CSVOptions) string {
+	switch v := value.(type) {
+	case nil:
+		return opts.Null
+	case string:
+		return v
+	case []byte:
+		return opts.Binary.encode(v)
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case time.Time:
+		return formatTime(c.typeName, v, " ")
+	}
+
+	if s, ok := formatNumber(value); ok {
+		return s
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,140 @@
+//Package export writes query results as CSV or JSON Lines, formatting each value by the
+//column's database type, as reported by the sqlanywhere driver.
+package export
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+//Querier queries the database, implemented by *sql.DB, *sql.Conn and *sql.Tx
+type Querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+//BinaryEncoding is how BINARY and LONG BINARY values are written
+type BinaryEncoding int
+
+const (
+	//Base64 writes binary values with standard base64 encoding
+	Base64 BinaryEncoding = iota
+
+	//Hex writes binary values as lowercase hexadecimal digits
+	Hex
+)
+
+func (e BinaryEncoding) encode(b []byte) string {
+	if e == Hex {
+		return hex.EncodeToString(b)
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+//Time layouts of DATE, TIME and TIMESTAMP values, which have no time zone
+const (
+	DateLayout      = "2006-01-02"
+	TimeLayout      = "15:04:05.999999"
+	TimestampLayout = "2006-01-02 15:04:05.999999"
+)
+
+//column is a column of the rows being exported
+type column struct {
+	name     string
+	typeName string
+}
+
+//columns returns the columns of the rows, with names made unique for use as a header or keys.
+//A repeated name has its position appended, such as expression_2.
+func columns(rows *sql.Rows) ([]column, error) {
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, fmt.Errorf("did not get column types: %v", err)
+	}
+
+	seen := map[string]bool{}
+	cols := make([]column, len(types))
+	for i, t := range types {
+		name := t.Name()
+		if seen[name] {
+			name = fmt.Sprintf("%s_%d", name, i+1)
+		}
+		seen[name] = true
+		cols[i] = column{name: name, typeName: t.DatabaseTypeName()}
+	}
+	return cols, nil
+}
+
+//scanner scans each row into values of the driver's types
+type scanner struct {
+	values []interface{}
+	dest   []interface{}
+}
+
+func newScanner(n int) *scanner {
+	s := &scanner{values: make([]interface{}, n), dest: make([]interface{}, n)}
+	for i := range s.values {
+		s.dest[i] = &s.values[i]
+	}
+	return s
+}
+
+func (s *scanner) scan(rows *sql.Rows) error {
+	if err := rows.Scan(s.dest...); err != nil {
+		return fmt.Errorf("did not scan row: %v", err)
+	}
+	return nil
+}
+
+//formatTime formats a DATE, TIME or TIMESTAMP value, with sep between the date and time of a TIMESTAMP
+func formatTime(typeName string, t time.Time, sep string) string {
+	switch typeName {
+	case "DATE":
+		return t.Format(DateLayout)
+	case "TIME":
+		return t.Format(TimeLayout)
+	default:
+		return t.Format(DateLayout) + sep + t.Format(TimeLayout)
+	}
+}
+
+//formatNumber formats an integer or floating point value, reporting false for other values
+func formatNumber(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case int32:
+		return strconv.FormatInt(int64(v), 10), true
+	case int16:
+		return strconv.FormatInt(int64(v), 10), true
+	case uint8:
+		return strconv.FormatUint(uint64(v), 10), true
+	case uint16:
+		return strconv.FormatUint(uint64(v), 10), true
+	case uint32:
+		return strconv.FormatUint(uint64(v), 10), true
+	case uint64:
+		return strconv.FormatUint(v, 10), true
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), true
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32), true
+	}
+	return "", false
+}
+
+//isFinite reports whether a number is neither infinite nor NaN, so it can be written as a JSON number
+func isFinite(value interface{}) bool {
+	switch v := value.(type) {
+	case float64:
+		return !math.IsInf(v, 0) && !math.IsNaN(v)
+	case float32:
+		return !math.IsInf(float64(v), 0) && !math.IsNaN(float64(v))
+	}
+	return true
+}
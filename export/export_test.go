@@ -0,0 +1,151 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mdcnz/sqlanywhere/internal/testdb"
+)
+
+const exportQuery = `select id, name, amount, ratio, flag, born, alarm, updated, data, name, null as nothing
+	from export_test order by id`
+
+func TestExport(t *testing.T) {
+	database := testdb.New(t)
+	defer database.Cleanup()
+
+	db, close := database.Open()
+	defer close()
+
+	database.Exec(db,
+		`create table export_test (
+			id int primary key,
+			name varchar(20) null,
+			amount numeric(10, 2) null,
+			ratio double null,
+			flag bit not null,
+			born date null,
+			alarm time null,
+			updated timestamp null,
+			data varbinary(10) null
+		)`,
+		`insert into export_test values (1, 'plain', 12.5, 0.25, 1, '2020-01-02', '03:04:05', '2020-01-02 03:04:05.123456', 0x0102ff)`,
+		`insert into export_test values (2, 'a, "quoted"
+line', -0.5, null, 0, null, null, null, null)`,
+		`insert into export_test values (3, null, null, null, 0, null, null, null, null)`,
+	)
+
+	ctx := context.Background()
+
+	t.Run("csv", func(t *testing.T) {
+		var b bytes.Buffer
+		n, err := QueryCSV(ctx, db, &b, &CSVOptions{Null: `\N`, LF: true}, exportQuery)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := "id,name,amount,ratio,flag,born,alarm,updated,data,name_10,nothing\n" +
+			"1,plain,12.50,0.25,1,2020-01-02,03:04:05,2020-01-02 03:04:05.123456,AQL/,plain,\\N\n" +
+			"2,\"a, \"\"quoted\"\"\nline\",-0.50,\\N,0,\\N,\\N,\\N,\\N,\"a, \"\"quoted\"\"\nline\",\\N\n" +
+			"3,\\N,\\N,\\N,0,\\N,\\N,\\N,\\N,\\N,\\N\n"
+		if n != 3 || b.String() != want {
+			t.Fatalf("want 3 rows\n%s\ngot %d rows\n%s", want, n, b.String())
+		}
+	})
+
+	t.Run("csv delimiter", func(t *testing.T) {
+		var b bytes.Buffer
+		if _, err := QueryCSV(ctx, db, &b, &CSVOptions{Delimiter: ';', NoHeader: true, Binary: Hex}, "select id, data from export_test where id = 1"); err != nil {
+			t.Fatal(err)
+		}
+		if want := "1;0102ff\r\n"; b.String() != want {
+			t.Fatalf("want %q, got %q", want, b.String())
+		}
+	})
+
+	t.Run("json lines", func(t *testing.T) {
+		var b bytes.Buffer
+		n, err := QueryJSONLines(ctx, db, &b, nil, exportQuery)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := `{"id":1,"name":"plain","amount":12.50,"ratio":0.25,"flag":true,"born":"2020-01-02","alarm":"03:04:05","updated":"2020-01-02T03:04:05.123456","data":"AQL/","name_10":"plain","nothing":null}` + "\n" +
+			`{"id":2,"name":"a, \"quoted\"\nline","amount":-0.50,"ratio":null,"flag":false,"born":null,"alarm":null,"updated":null,"data":null,"name_10":"a, \"quoted\"\nline","nothing":null}` + "\n" +
+			`{"id":3,"name":null,"amount":null,"ratio":null,"flag":false,"born":null,"alarm":null,"updated":null,"data":null,"name_10":null,"nothing":null}` + "\n"
+		if n != 3 || b.String() != want {
+			t.Fatalf("want 3 rows\n%s\ngot %d rows\n%s", want, n, b.String())
+		}
+	})
+
+	//the conversion fails at the third row, or sooner if the server fetches ahead
+	const failingQuery = "select cast(if row_num < 3 then '1' else 'x' endif as int) as n from sa_rowgenerator(1, 5)"
+
+	t.Run("csv failing row", func(t *testing.T) {
+		var b bytes.Buffer
+		n, err := QueryCSV(ctx, db, &b, &CSVOptions{LF: true}, failingQuery)
+		if err == nil {
+			t.Fatal("want conversion error")
+		}
+		if lines := strings.Count(b.String(), "\n"); n > 0 && lines != int(n)+1 {
+			t.Fatalf("want header and %d rows written before the error, got %q", n, b.String())
+		}
+	})
+
+	t.Run("json lines failing row", func(t *testing.T) {
+		var b bytes.Buffer
+		n, err := QueryJSONLines(ctx, db, &b, nil, failingQuery)
+		if err == nil {
+			t.Fatal("want conversion error")
+		}
+		if lines := strings.Count(b.String(), "\n"); lines != int(n) {
+			t.Fatalf("want %d rows written before the error, got %q", n, b.String())
+		}
+	})
+
+	t.Run("column types", func(t *testing.T) {
+		rows, err := db.Query(exportQuery)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+
+		types, err := rows.ColumnTypes()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if name := types[2].DatabaseTypeName(); name != "DECIMAL" {
+			t.Fatalf("want DECIMAL, got %s", name)
+		}
+		if precision, scale, ok := types[2].DecimalSize(); !ok || precision != 10 || scale != 2 {
+			t.Fatalf("want decimal size 10, 2, got %d, %d, %v", precision, scale, ok)
+		}
+		if nullable, ok := types[4].Nullable(); !ok || nullable {
+			t.Fatalf("want flag not nullable, got %v, %v", nullable, ok)
+		}
+		if scanType := types[7].ScanType().String(); scanType != "time.Time" {
+			t.Fatalf("want time.Time, got %s", scanType)
+		}
+	})
+}
+
+func TestDecimalNumber(t *testing.T) {
+	for s, want := range map[string]string{
+		"12.50": "12.50",
+		"-.5":   "-0.5",
+		".5":    "0.5",
+		"5.":    "5.0",
+		"-3":    "-3",
+		"true":  "",
+		"":      "",
+		"1e5":   "1e5",
+		"NaN":   "",
+	} {
+		if got, ok := decimalNumber(s); got != want || ok != (want != "") {
+			t.Errorf("%q: want %q, got %q %v", s, want, got, ok)
+		}
+	}
+}
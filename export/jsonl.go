@@ -0,0 +1,146 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+//JSONOptions configure JSONLines
+type JSONOptions struct {
+	//Binary is the encoding of binary values, written as strings
+	Binary BinaryEncoding
+}
+
+//JSONLines writes each row to w as a JSON object on its own line, keyed by column name in column order.
+//opts may be nil. NULL is null, numbers and DECIMAL values are numbers written in full, BIT is a boolean,
+//and times are strings in ISO 8601 format without a time zone. Infinite and NaN values are strings.
+//It returns the number of rows written, which are written to w even if a later row fails.
+//The rows are not closed.
+func JSONLines(w io.Writer, rows *sql.Rows, opts *JSONOptions) (n int64, err error) {
+	if opts == nil {
+		opts = &JSONOptions{}
+	}
+
+	cols, err := columns(rows)
+	if err != nil {
+		return 0, err
+	}
+
+	keys := make([][]byte, len(cols))
+	for i, c := range cols {
+		keys[i] = jsonString(c.name)
+	}
+
+	bw := bufio.NewWriter(w)
+	defer func() {
+		if flushErr := bw.Flush(); err == nil {
+			err = flushErr
+		}
+	}()
+
+	s := newScanner(len(cols))
+	var line bytes.Buffer
+	for rows.Next() {
+		if err := s.scan(rows); err != nil {
+			return n, err
+		}
+
+		line.Reset()
+		line.WriteByte('{')
+		for i, value := range s.values {
+			if i > 0 {
+				line.WriteByte(',')
+			}
+			line.Write(keys[i])
+			line.WriteByte(':')
+			writeJSONValue(&line, cols[i], value, opts)
+		}
+		line.WriteString("}\n")
+
+		if _, err := bw.Write(line.Bytes()); err != nil {
+			return n, fmt.Errorf("did not write row: %v", err)
+		}
+		n++
+	}
+	return n, rows.Err()
+}
+
+//QueryJSONLines writes the rows of the query to w as JSON Lines; see JSONLines
+func QueryJSONLines(ctx context.Context, q Querier, w io.Writer, opts *JSONOptions, query string, args ...interface{}) (int64, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	return JSONLines(w, rows, opts)
+}
+
+func writeJSONValue(b *bytes.Buffer, c column, value interface{}, opts *JSONOptions) {
+	switch v := value.(type) {
+	case nil:
+		b.WriteString("null")
+	case string:
+		if number, ok := decimalNumber(v); ok && c.typeName == "DECIMAL" {
+			b.WriteString(number)
+		} else {
+			b.Write(jsonString(v))
+		}
+	case []byte:
+		b.Write(jsonString(opts.Binary.encode(v)))
+	case bool:
+		if v {
+			b.WriteString("true")
+		} else {
+			b.WriteString("false")
+		}
+	case time.Time:
+		b.Write(jsonString(formatTime(c.typeName, v, "T")))
+	default:
+		s, ok := formatNumber(value)
+		if !ok {
+			s = fmt.Sprint(value)
+		}
+		if ok && isFinite(value) {
+			b.WriteString(s)
+		} else {
+			b.Write(jsonString(s))
+		}
+	}
+}
+
+//decimalNumber returns a DECIMAL value as a JSON number, adding the zeros the server may omit around
+//the decimal point, such as in -.5, and reporting false if it is not a number
+func decimalNumber(s string) (string, bool) {
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	if strings.HasPrefix(s, ".") {
+		s = "0" + s
+	}
+	if strings.HasSuffix(s, ".") {
+		s += "0"
+	}
+
+	if s == "" || s[0] < '0' || s[0] > '9' || !json.Valid([]byte(s)) {
+		return "", false
+	}
+	return sign + s, true
+}
+
+//jsonString returns s as a JSON string, without escaping HTML characters
+func jsonString(s string) []byte {
+	var b bytes.Buffer
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(s)
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n"))
+}
@@ -0,0 +1,80 @@
+package sqlanywhere
+
+import (
+	"context"
+	"time"
+)
+
+const defaultPingTimeout = 5 * time.Second
+
+//track registers an open connection with the connector, starting the health checker if enabled
+func (c *connector) track(con *connection) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.connections == nil {
+		c.connections = make(map[*connection]struct{})
+	}
+	c.connections[con] = struct{}{}
+
+	if c.healthCheckInterval > 0 && c.done == nil {
+		c.done = make(chan struct{})
+		go c.healthCheck(c.done)
+	}
+}
+
+//untrack removes a closed connection from the connector
+func (c *connector) untrack(con *connection) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.connections, con)
+}
+
+//healthCheck periodically pings idle connections until done is closed
+func (c *connector) healthCheck(done chan struct{}) {
+	ticker := time.NewTicker(c.healthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			c.checkIdle()
+		}
+	}
+}
+
+//checkIdle pings each connection currently idle in the pool
+func (c *connector) checkIdle() {
+	c.mu.Lock()
+	connections := make([]*connection, 0, len(c.connections))
+	for con := range c.connections {
+		connections = append(connections, con)
+	}
+	c.mu.Unlock()
+
+	for _, con := range connections {
+		con.checkIdle(c.pingTimeout)
+	}
+}
+
+//checkIdle pings the connection if it is idle, marking it invalid if the ping fails.
+//The pool discards invalid connections in ResetSession, before they are used again.
+//The connection is locked while pinged, so it is neither handed out nor closed meanwhile.
+func (con *connection) checkIdle(timeout time.Duration) {
+	con.mu.Lock()
+	defer con.mu.Unlock()
+
+	if !con.idle || !con.isValid() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := con.Ping(ctx); err != nil {
+		con.valid = false
+	}
+}
@@ -0,0 +1,13 @@
+//go:build linux && leakcheck
+// +build linux,leakcheck
+
+package sqlanywhere
+
+//#include <malloc.h>
+import "C"
+
+//cHeapInUse returns the bytes of C heap allocated and not yet freed, for the leak tests.
+//It needs glibc 2.33 or later, so it is only built with the leakcheck tag: go test -tags leakcheck
+func cHeapInUse() uint64 {
+	return uint64(C.mallinfo2().uordblks)
+}
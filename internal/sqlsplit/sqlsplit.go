@@ -0,0 +1,359 @@
+//Package sqlsplit splits SQL scripts into statements, as dbisql does.
+//
+//Statements end with a semicolon, or a line containing only "go". Semicolons within
+//quotes, comments, BEGIN ... END, CASE ... END and IF ... END IF blocks do not end a statement,
+//so procedure bodies and control statements can be written as for dbisql.
+//A "go" line always ends a statement.
+//
+//Another delimiter may be chosen, as with the dbisql command_delimiter option. Statements then
+//end only at the delimiter or a "go" line, and semicolons and blocks are not significant.
+package sqlsplit
+
+import (
+	"fmt"
+	"strings"
+)
+
+//Statement is a statement of a script
+type Statement struct {
+	SQL string
+
+	//Line is the line of the script the statement starts on, counting from 1
+	Line int
+}
+
+//Split splits the script into statements delimited by semicolons, omitting those empty or
+//containing only comments. It returns an error for an unterminated quote or comment.
+func Split(script string) ([]Statement, error) {
+	return SplitDelimited(script, ";")
+}
+
+//SplitDelimited splits the script into statements ending with delimiter. A delimiter made of
+//letters, digits and underscores, such as GO, matches whole words ignoring case. Empty is a semicolon.
+func SplitDelimited(script string, delimiter string) ([]Statement, error) {
+	s := newSplitter(script, delimiter)
+	if err := s.scan(); err != nil {
+		return nil, err
+	}
+	s.end(len(s.script))
+	return s.statements, nil
+}
+
+//Complete reports whether the script ends with a delimited statement, outside of any quote, comment
+//or block, as an interactive client decides whether to execute the lines entered so far
+func Complete(script string, delimiter string) bool {
+	s := newSplitter(script, delimiter)
+	if err := s.scan(); err != nil {
+		return false
+	}
+	return s.start < 0 && s.pending == "" && len(s.statements) > 0
+}
+
+func newSplitter(script string, delimiter string) *splitter {
+	if delimiter == "" {
+		delimiter = ";"
+	}
+
+	s := &splitter{script: script, delimiter: delimiter, line: 1, start: -1, lineStart: true, atStatement: true}
+	s.symbolic = delimiter != ";" && !isWordDelimiter(delimiter)
+	return s
+}
+
+//splitter scans a script, tracking the nesting of blocks
+type splitter struct {
+	script    string
+	delimiter string
+
+	//symbolic is true for a delimiter other than a semicolon that is not a word, such as $$
+	symbolic bool
+
+	pos  int
+	line int
+
+	//lineStart is true at the start of each line, where a "go" line may be
+	lineStart bool
+
+	//start is the offset of the current statement, and startLine its line, or -1 before its first token
+	start     int
+	startLine int
+
+	//depth is the number of blocks open
+	depth int
+
+	//pending is BEGIN or END, when deciding from the next word whether it opens or closes a block
+	pending string
+
+	//atStatement is true where a statement may start, so an IF is a statement opening a block,
+	//rather than an IF expression or IF EXISTS clause
+	atStatement bool
+
+	//previous is the previous word
+	previous string
+
+	statements []Statement
+}
+
+//loopWords follow END to close a loop, which is within a block so not counted as opened
+var loopWords = map[string]bool{
+	"LOOP":   true,
+	"WHILE":  true,
+	"FOR":    true,
+	"REPEAT": true,
+}
+
+//statementWords are followed by a statement
+var statementWords = map[string]bool{
+	"THEN": true,
+	"ELSE": true,
+	"DO":   true,
+	"LOOP": true,
+}
+
+//transactionWords follow BEGIN to begin a transaction rather than a block
+var transactionWords = map[string]bool{
+	"TRANSACTION": true,
+	"TRAN":        true,
+	"DISTRIBUTED": true,
+}
+
+//scan scans the script, ending each statement at its delimiter but leaving the last open if undelimited
+func (s *splitter) scan() error {
+	for s.pos < len(s.script) {
+		if s.lineStart {
+			s.lineStart = false
+			if s.goLine() {
+				continue
+			}
+		}
+
+		c := s.script[s.pos]
+		switch {
+		case c == '\n':
+			s.line++
+			s.pos++
+			s.lineStart = true
+
+		case c == ' ' || c == '\t' || c == '\r':
+			s.pos++
+
+		case s.hasPrefix("--") || s.hasPrefix("//"):
+			s.skipLineComment()
+
+		case s.hasPrefix("/*"):
+			if err := s.skipBlockComment(); err != nil {
+				return err
+			}
+
+		case c == '\'' || c == '"':
+			s.resolvePending("")
+			s.atStatement = false
+			s.mark()
+			if err := s.skipQuoted(c); err != nil {
+				return err
+			}
+
+		case s.symbolic && s.hasPrefix(s.delimiter):
+			s.end(s.pos)
+			s.pos += len(s.delimiter)
+
+		case c == ';' && s.delimiter == ";":
+			s.resolvePending("")
+			if s.depth == 0 {
+				s.end(s.pos)
+			}
+			s.atStatement = true
+			s.pos++
+
+		case isWordByte(c):
+			s.word()
+
+		default:
+			s.resolvePending("")
+			s.atStatement = false
+			s.mark()
+			s.pos++
+		}
+	}
+	return nil
+}
+
+//goLine ends the statement if the line contains only "go", returning true after skipping it
+func (s *splitter) goLine() bool {
+	end := strings.IndexByte(s.script[s.pos:], '\n')
+	if end < 0 {
+		end = len(s.script)
+	} else {
+		end += s.pos
+	}
+
+	if !strings.EqualFold(strings.TrimSpace(s.script[s.pos:end]), "go") {
+		return false
+	}
+
+	s.end(s.pos)
+	s.depth = 0
+	s.pos = end
+	return true
+}
+
+func (s *splitter) hasPrefix(prefix string) bool {
+	return strings.HasPrefix(s.script[s.pos:], prefix)
+}
+
+//mark starts the current statement, if not already started
+func (s *splitter) mark() {
+	if s.start < 0 {
+		s.start = s.pos
+		s.startLine = s.line
+	}
+}
+
+//end ends the current statement before offset end
+func (s *splitter) end(end int) {
+	if s.start >= 0 {
+		if sql := strings.TrimSpace(s.script[s.start:end]); sql != "" {
+			s.statements = append(s.statements, Statement{SQL: sql, Line: s.startLine})
+		}
+	}
+	s.start = -1
+	s.pending = ""
+	s.atStatement = true
+}
+
+func (s *splitter) skipLineComment() {
+	for s.pos < len(s.script) && s.script[s.pos] != '\n' {
+		s.pos++
+	}
+}
+
+func (s *splitter) skipBlockComment() error {
+	line := s.line
+	s.pos += 2
+	for s.pos < len(s.script) {
+		if s.hasPrefix("*/") {
+			s.pos += 2
+			return nil
+		}
+		if s.script[s.pos] == '\n' {
+			s.line++
+		}
+		s.pos++
+	}
+	return fmt.Errorf("line %d: unterminated comment", line)
+}
+
+//skipQuoted skips a string or quoted identifier. A doubled quote is part of it.
+func (s *splitter) skipQuoted(quote byte) error {
+	line := s.line
+	s.pos++
+	for s.pos < len(s.script) {
+		c := s.script[s.pos]
+		s.pos++
+
+		if c == '\n' {
+			s.line++
+		}
+		if c != quote {
+			continue
+		}
+		if s.pos < len(s.script) && s.script[s.pos] == quote {
+			s.pos++
+			continue
+		}
+		return nil
+	}
+	return fmt.Errorf("line %d: unterminated quote %c", line, quote)
+}
+
+func isWordByte(c byte) bool {
+	return c >= 'a' && c <= 'z' ||
+		c >= 'A' && c <= 'Z' ||
+		c >= '0' && c <= '9' ||
+		c == '_' || c == '$' || c == '#' || c == '@' ||
+		c >= 0x80
+}
+
+func isWordDelimiter(delimiter string) bool {
+	for i := 0; i < len(delimiter); i++ {
+		c := delimiter[i]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+//word scans a word, counting the blocks it opens or closes
+func (s *splitter) word() {
+	start := s.pos
+	for s.pos < len(s.script) && isWordByte(s.script[s.pos]) {
+		if s.symbolic && s.pos > start && s.hasPrefix(s.delimiter) {
+			break
+		}
+		s.pos++
+	}
+	word := strings.ToUpper(s.script[start:s.pos])
+
+	if s.delimiter != ";" && !s.symbolic && strings.EqualFold(word, s.delimiter) {
+		s.end(start)
+		return
+	}
+
+	if s.start < 0 {
+		s.start = start
+		s.startLine = s.line
+	}
+
+	if s.resolvePending(word) {
+		s.atStatement = false
+		return
+	}
+
+	switch word {
+	case "BEGIN", "END":
+		s.pending = word
+	case "CASE":
+		s.depth++
+	case "IF":
+		//ELSE IF is a synonym for ELSEIF, continuing the enclosing IF
+		if s.atStatement && s.previous != "ELSE" {
+			s.depth++
+		}
+	case "ENDIF":
+		s.close()
+	}
+
+	s.atStatement = statementWords[word]
+	s.previous = word
+}
+
+//close closes the innermost block
+func (s *splitter) close() {
+	if s.depth > 0 {
+		s.depth--
+	}
+}
+
+//resolvePending decides whether a pending BEGIN or END opens or closes a block, given the next word,
+//or "" if the next token is not a word. It returns true if the word was consumed, as in END IF.
+func (s *splitter) resolvePending(next string) bool {
+	pending := s.pending
+	s.pending = ""
+
+	switch pending {
+	case "BEGIN":
+		if transactionWords[next] {
+			return true
+		}
+		s.depth++
+		s.atStatement = true
+
+	case "END":
+		if loopWords[next] {
+			return true
+		}
+		s.close()
+		return next == "IF" || next == "CASE"
+	}
+	return false
+}
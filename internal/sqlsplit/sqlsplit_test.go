@@ -0,0 +1,172 @@
+package sqlsplit
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   []Statement
+	}{
+		{
+			"semicolons",
+			"create table t (id int);\ninsert into t values (1);\n\n  select * from t",
+			[]Statement{
+				{"create table t (id int)", 1},
+				{"insert into t values (1)", 2},
+				{"select * from t", 4},
+			},
+		},
+		{
+			"go lines",
+			"select 1\ngo\nselect 2\n  GO  \n\nselect 3",
+			[]Statement{{"select 1", 1}, {"select 2", 3}, {"select 3", 6}},
+		},
+		{
+			"quotes and comments",
+			"select 'a;b''c', \"x;y\" -- one;\nfrom t; /* two;\nthree; */ select 2 // four;\n;",
+			[]Statement{
+				{"select 'a;b''c', \"x;y\" -- one;\nfrom t", 1},
+				{"select 2 // four;", 3},
+			},
+		},
+		{
+			"comment only",
+			"-- nothing here;\n/* or here */;",
+			nil,
+		},
+		{
+			"procedure",
+			"create procedure p()\nbegin\n  declare x int;\n  if x > 0 then\n    set x = case when x > 1 then 2 else 1 end;\n  elseif x < 0 then\n    set x = 0;\n  end if;\n  while x < 10 loop\n    set x = x + 1;\n  end loop;\nend;\ncall p();",
+			[]Statement{
+				{"create procedure p()\nbegin\n  declare x int;\n  if x > 0 then\n    set x = case when x > 1 then 2 else 1 end;\n  elseif x < 0 then\n    set x = 0;\n  end if;\n  while x < 10 loop\n    set x = x + 1;\n  end loop;\nend", 1},
+				{"call p()", 13},
+			},
+		},
+		{
+			"case statement",
+			"begin case x when 1 then set y = 1; else set y = 2; end case; end; select 1;",
+			[]Statement{
+				{"begin case x when 1 then set y = 1; else set y = 2; end case; end", 1},
+				{"select 1", 1},
+			},
+		},
+		{
+			"top level if",
+			"if exists (select 1 from t) then drop table t; end if;\ndrop table if exists u;\nselect if x > 1 then 'a' else 'b' endif;",
+			[]Statement{
+				{"if exists (select 1 from t) then drop table t; end if", 1},
+				{"drop table if exists u", 2},
+				{"select if x > 1 then 'a' else 'b' endif", 3},
+			},
+		},
+		{
+			"else if",
+			"begin if a then set x = 1; else if b then set x = 2; end if; end; select 1;",
+			[]Statement{
+				{"begin if a then set x = 1; else if b then set x = 2; end if; end", 1},
+				{"select 1", 1},
+			},
+		},
+		{
+			"transactions",
+			"begin transaction;\ninsert into t values (1);\ncommit;",
+			[]Statement{{"begin transaction", 1}, {"insert into t values (1)", 2}, {"commit", 3}},
+		},
+		{
+			"go ends unbalanced block",
+			"create procedure p() begin\nselect 1;\ngo\nselect 2;",
+			[]Statement{{"create procedure p() begin\nselect 1;", 1}, {"select 2", 4}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Split(test.script)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("want %q, got %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestSplitErrors(t *testing.T) {
+	for _, script := range []string{
+		"select 1;\nselect 'unterminated;",
+		"select 1;\n/* unterminated",
+		"select \"unterminated",
+	} {
+		if _, err := Split(script); err == nil {
+			t.Fatalf("want error splitting %q", script)
+		}
+	}
+}
+
+func TestSplitDelimited(t *testing.T) {
+	tests := []struct {
+		delimiter string
+		script    string
+		want      []Statement
+	}{
+		{
+			"$$",
+			"create procedure p() begin select 1; end $$ select '$$'; select 2$$",
+			[]Statement{{"create procedure p() begin select 1; end", 1}, {"select '$$'; select 2", 1}},
+		},
+		{
+			"GO",
+			"select 1 go select gone;\nselect 2 Go\ngo\nselect 3",
+			[]Statement{{"select 1", 1}, {"select gone;\nselect 2", 1}, {"select 3", 4}},
+		},
+		{
+			"",
+			"select 1; select 2",
+			[]Statement{{"select 1", 1}, {"select 2", 1}},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := SplitDelimited(test.script, test.delimiter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Fatalf("delimiter %q: want %q, got %q", test.delimiter, test.want, got)
+		}
+	}
+}
+
+func TestComplete(t *testing.T) {
+	tests := []struct {
+		script    string
+		delimiter string
+		want      bool
+	}{
+		{"", "", false},
+		{"select 1", "", false},
+		{"select 1;", "", true},
+		{"select 1; -- done", "", true},
+		{"select 1;\nselect 2", "", false},
+		{"select 'a;", "", false},
+		{"/* select 1; ", "", false},
+		{"create procedure p() begin select 1;", "", false},
+		{"create procedure p() begin select 1; end", "", false},
+		{"create procedure p() begin select 1; end;", "", true},
+		{"create procedure p() begin select 1; end\ngo", "", true},
+		{"select 1;", "$$", false},
+		{"select 1; $$", "$$", true},
+		{"select 1\ngo\n", "go", true},
+	}
+
+	for _, test := range tests {
+		if got := Complete(test.script, test.delimiter); got != test.want {
+			t.Errorf("%q with delimiter %q: want %v, got %v", test.script, test.delimiter, test.want, got)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+//Package testdb creates temporary databases on the test server, for the tests of packages built on the driver.
+//The server is started as described in the readme, named sqlanywhere-db-server with user dba and password sqlsql.
+package testdb
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"fmt"
+	"math"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mdcnz/sqlanywhere"
+)
+
+const utilityConnectionString = "uid=dba;pwd=sqlsql;dbn=utility_db;servername=sqlanywhere-db-server;charset=utf-8;"
+
+//Database is a temporary database, dropped by Cleanup
+type Database struct {
+	Name     string
+	filename string
+	utility  *sql.DB
+	t        testing.TB
+}
+
+//New creates and starts a new database with a random name
+func New(t testing.TB) *Database {
+	n, err := rand.Int(rand.Reader, big.NewInt(math.MaxInt64))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name := fmt.Sprintf("sqlany_test_%d", n.Int64())
+	filename := filepath.Join(os.TempDir(), name+".db")
+
+	//a db file may be left over from prior failed test; remove it
+	os.Remove(filename)
+
+	utility, err := sql.Open(sqlanywhere.DriverName, utilityConnectionString)
+	if err != nil {
+		t.Fatalf("did not open utility db: %v", err)
+	}
+
+	db := &Database{Name: name, filename: filename, utility: utility, t: t}
+
+	db.exec(`CREATE DATABASE '%s' DBA USER 'dba' DBA PASSWORD 'sqlsql' ENCODING 'UTF-8' COLLATION 'UCA' NCHAR COLLATION 'UCA'`, filename)
+	db.exec(`START DATABASE '%s' AS %s AUTOSTOP OFF`, filename, name)
+
+	return db
+}
+
+//ConnectionString connects to the database as dba
+func (db *Database) ConnectionString() string {
+	return fmt.Sprintf("uid=dba;pwd=sqlsql;dbn=%s;servername=sqlanywhere-db-server;charset=utf-8;", db.Name)
+}
+
+//Open opens a pool of connections to the database, closed by the returned func
+func (db *Database) Open() (*sql.DB, func()) {
+	pool, err := sql.Open(sqlanywhere.DriverName, db.ConnectionString())
+	if err != nil {
+		db.t.Fatalf("did not open %s: %v", db.Name, err)
+	}
+
+	return pool, func() {
+		if err := pool.Close(); err != nil {
+			db.t.Errorf("did not close %s: %v", db.Name, err)
+		}
+	}
+}
+
+//Exec executes each statement on the pool, failing the test on error
+func (db *Database) Exec(pool *sql.DB, statements ...string) {
+	for _, statement := range statements {
+		if _, err := pool.Exec(statement); err != nil {
+			db.t.Fatalf("%s: %v", statement, err)
+		}
+	}
+}
+
+//Cleanup stops and drops the database
+func (db *Database) Cleanup() {
+	db.exec("STOP DATABASE %s UNCONDITIONALLY", db.Name)
+	db.exec("DROP DATABASE '%s'", db.filename)
+
+	if err := db.utility.Close(); err != nil {
+		db.t.Fatalf("did not close utility db: %v", err)
+	}
+}
+
+func (db *Database) exec(format string, args ...interface{}) {
+	query := fmt.Sprintf(format, args...)
+	if _, err := db.utility.Exec(query); err != nil {
+		db.t.Fatalf("%s: %v", query, err)
+	}
+}
@@ -0,0 +1,43 @@
+package sqlanywhere
+
+import "fmt"
+
+//MessageType is the type of a message from the server
+type MessageType int
+
+//Message types, as a_sqlany_message_type
+const (
+	MessageInfo MessageType = iota
+	MessageWarning
+	MessageAction
+	MessageStatus
+	MessageProgress
+)
+
+var messageTypeNames = []string{"INFO", "WARNING", "ACTION", "STATUS", "PROGRESS"}
+
+func (t MessageType) String() string {
+	if t >= 0 && int(t) < len(messageTypeNames) {
+		return messageTypeNames[t]
+	}
+	return fmt.Sprintf("MessageType(%d)", int(t))
+}
+
+//Message is a message sent to the client by the server, received by the handler set with WithMessageHandler
+type Message struct {
+	Type MessageType
+
+	//Code is the SQLCODE of the message
+	Code int
+	Text string
+}
+
+//messageCallback passes the message to the handler of the connection with the handle.
+//It is called from the client library on the goroutine making the request.
+func messageCallback(handle uintptr, t MessageType, code int, text string) {
+	con, ok := handleConnection(handle)
+	if !ok || con.connector == nil || con.connector.messageHandler == nil {
+		return
+	}
+	con.connector.messageHandler(Message{Type: t, Code: code, Text: text})
+}
@@ -0,0 +1,30 @@
+package sqlanywhere
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestMessageHandler(t *testing.T) {
+	testdb := NewTestDB(t)
+	defer testdb.Cleanup()
+
+	var messages []Message
+	connector, err := NewConnector(testdb.ConnectionString(), WithMessageHandler(func(m Message) {
+		messages = append(messages, m)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := sql.OpenDB(connector)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec("message 'hello' type warning to client"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(messages) != 1 || messages[0].Text != "hello" || messages[0].Type != MessageWarning {
+		t.Fatalf("want warning hello, got %+v", messages)
+	}
+}
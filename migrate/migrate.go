@@ -0,0 +1,549 @@
+//go:build go1.16
+// +build go1.16
+
+//Package migrate applies schema migrations to a SQL Anywhere database.
+//
+//Migrations are files named <version>_<name>.up.sql, with an optional <version>_<name>.down.sql
+//reverting it, where version is a positive integer. They are applied in order of version, and
+//recorded with a checksum in a table, so each is applied once and changes to applied files are detected.
+//
+//Files are split into statements as by dbisql, so procedure bodies may contain semicolons
+//within BEGIN ... END, and statements may be separated by "go" lines.
+//
+//Each migration runs in a transaction, recorded as applied when it commits. The server commits
+//before and after each DDL statement though, so a migration containing DDL that fails part way
+//is not rolled back before the failing statement: prefer one DDL statement per migration.
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mdcnz/sqlanywhere/internal/sqlsplit"
+)
+
+//ErrChecksumMismatch is returned when a migration has changed since it was applied
+var ErrChecksumMismatch = errors.New("migration changed since applied")
+
+//ErrNoDown is returned when reverting a migration without a down file
+var ErrNoDown = errors.New("migration has no down file")
+
+//ErrUnknownVersion is returned when reverting an applied migration that has no files
+var ErrUnknownVersion = errors.New("applied migration has no files")
+
+//Migration is a version of the schema, with the SQL applying and reverting it
+type Migration struct {
+	Version int64
+	Name    string
+
+	//Up applies the migration, and Down reverts it, empty if there is no down file
+	Up   string
+	Down string
+
+	//Checksum is the hex SHA-256 of Up
+	Checksum string
+}
+
+//Applied is a migration recorded as applied
+type Applied struct {
+	Version   int64
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+//MigrationError is returned when a statement of a migration fails. The migration is not recorded as applied.
+type MigrationError struct {
+	Version int64
+	File    string
+
+	//Line is the line of the file the statement starts on
+	Line      int
+	Statement string
+	Err       error
+}
+
+func (e *MigrationError) Error() string {
+	return fmt.Sprintf("migration %s, line %d: %v", e.File, e.Line, e.Err)
+}
+
+func (e *MigrationError) Unwrap() error {
+	return e.Err
+}
+
+//LockMode is how a Migrator prevents concurrent migrations of a database
+type LockMode int
+
+const (
+	//LockMutex locks a connection scoped mutex, supported by SQL Anywhere 17 and later,
+	//on the connection migrating, so migrating needs one connection
+	LockMutex LockMode = iota
+
+	//LockTable locks a table, created if necessary, in exclusive mode for the duration of a transaction.
+	//Migrations commit, so the lock is held on a second connection, and the pool must allow two.
+	//Up and Down return an error rather than wait if sql.DB.SetMaxOpenConns limits the pool to one.
+	LockTable
+)
+
+//Migrator applies and reverts migrations, holding a lock on the server while doing so
+type Migrator struct {
+	db         *sql.DB
+	migrations []*Migration
+
+	table    string
+	lockMode LockMode
+	lockName string
+
+	//dryRun receives the statements that would be executed, nil unless a dry run
+	dryRun io.Writer
+}
+
+//Option configures a Migrator
+type Option func(*Migrator)
+
+//WithTable records applied migrations in table, instead of schema_migrations.
+//The table may be qualified by its owner, as owner.table, and is otherwise owned by the user connected.
+func WithTable(table string) Option {
+	return func(m *Migrator) {
+		m.table = table
+	}
+}
+
+//WithLock locks the named mutex or table while migrating, instead of the mutex migrate_lock
+func WithLock(mode LockMode, name string) Option {
+	return func(m *Migrator) {
+		m.lockMode = mode
+		m.lockName = name
+	}
+}
+
+//WithDryRun writes the statements of the migrations to w rather than executing them.
+//Nothing is created, locked or recorded on the server.
+func WithDryRun(w io.Writer) Option {
+	return func(m *Migrator) {
+		m.dryRun = w
+	}
+}
+
+//fileName matches the names of migration files
+var fileName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+//New reads the migrations in the root directory of fsys. Files not named as migrations are ignored.
+func New(db *sql.DB, fsys fs.FS, opts ...Option) (*Migrator, error) {
+	m := &Migrator{
+		db:       db,
+		table:    "schema_migrations",
+		lockMode: LockMutex,
+		lockName: "migrate_lock",
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("did not read migrations: %v", err)
+	}
+
+	versions := make(map[int64]*Migration)
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s: invalid version", entry.Name())
+		}
+
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("did not read migration %s: %v", entry.Name(), err)
+		}
+
+		migration, ok := versions[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			versions[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %s: version %d is also named %s", entry.Name(), version, migration.Name)
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(content)
+			sum := sha256.Sum256(content)
+			migration.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	for _, migration := range versions {
+		if migration.Checksum == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", migration.Version, migration.Name)
+		}
+		m.migrations = append(m.migrations, migration)
+	}
+	sort.Slice(m.migrations, func(i, j int) bool {
+		return m.migrations[i].Version < m.migrations[j].Version
+	})
+
+	return m, nil
+}
+
+//Migrations returns the migrations read, in order of version
+func (m *Migrator) Migrations() []*Migration {
+	return m.migrations
+}
+
+//Applied returns the migrations recorded as applied, in order of version
+func (m *Migrator) Applied(ctx context.Context) ([]Applied, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	return m.applied(ctx, conn)
+}
+
+//Up applies the migrations not yet applied, in order of version, returning those applied.
+//It stops at the first that fails, returning a *MigrationError.
+func (m *Migrator) Up(ctx context.Context) ([]*Migration, error) {
+	var done []*Migration
+
+	err := m.run(ctx, func(conn *sql.Conn, applied map[int64]Applied) error {
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+
+			if err := m.apply(ctx, conn, migration, true); err != nil {
+				return err
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+
+	return done, err
+}
+
+//Down reverts the last steps migrations applied, latest first, returning those reverted
+func (m *Migrator) Down(ctx context.Context, steps int) ([]*Migration, error) {
+	var done []*Migration
+
+	err := m.run(ctx, func(conn *sql.Conn, applied map[int64]Applied) error {
+		versions := make([]int64, 0, len(applied))
+		for version := range applied {
+			versions = append(versions, version)
+		}
+		sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+
+		for i := 0; i < steps && i < len(versions); i++ {
+			migration := m.find(versions[i])
+			if migration == nil {
+				return fmt.Errorf("migration %d: %w", versions[i], ErrUnknownVersion)
+			}
+			if migration.Down == "" {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, ErrNoDown)
+			}
+
+			if err := m.apply(ctx, conn, migration, false); err != nil {
+				return err
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+
+	return done, err
+}
+
+func (m *Migrator) find(version int64) *Migration {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration
+		}
+	}
+	return nil
+}
+
+//run locks the database, checks the applied migrations are unchanged, and calls fn on a single connection
+func (m *Migrator) run(ctx context.Context, fn func(*sql.Conn, map[int64]Applied) error) (err error) {
+	var conn *sql.Conn
+	if m.dryRun != nil {
+		if conn, err = m.db.Conn(ctx); err != nil {
+			return err
+		}
+		defer conn.Close()
+	} else {
+		var unlock func() error
+		if conn, unlock, err = m.lock(ctx); err != nil {
+			return err
+		}
+		defer func() {
+			if unlockErr := unlock(); err == nil {
+				err = unlockErr
+			}
+		}()
+
+		if _, err := conn.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			version BIGINT NOT NULL PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			checksum CHAR(64) NOT NULL,
+			applied_at TIMESTAMP NOT NULL DEFAULT CURRENT TIMESTAMP
+		)`, m.table)); err != nil {
+			return fmt.Errorf("did not create migrations table: %v", err)
+		}
+	}
+
+	list, err := m.applied(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	applied := make(map[int64]Applied, len(list))
+	for _, a := range list {
+		if migration := m.find(a.Version); migration != nil && migration.Checksum != a.Checksum {
+			return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, ErrChecksumMismatch)
+		}
+		applied[a.Version] = a
+	}
+
+	return fn(conn, applied)
+}
+
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) ([]Applied, error) {
+	owner, name := splitName(m.table)
+
+	creator, args := "USER_ID()", []interface{}{name}
+	if owner != "" {
+		creator, args = "USER_ID(?)", append(args, owner)
+	}
+
+	var exists int
+	err := conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM SYS.SYSTAB WHERE table_name = ? AND creator = "+creator, args...).Scan(&exists)
+	if err != nil || exists == 0 {
+		return nil, err
+	}
+
+	rows, err := conn.QueryContext(ctx, fmt.Sprintf("SELECT version, name, checksum, applied_at FROM %s ORDER BY version", m.table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var applied []Applied
+	for rows.Next() {
+		var a Applied
+		if err := rows.Scan(&a.Version, &a.Name, &a.Checksum, &a.AppliedAt); err != nil {
+			return nil, err
+		}
+		applied = append(applied, a)
+	}
+	return applied, rows.Err()
+}
+
+//splitName returns the owner and name of a table or mutex, without quotes, the owner empty if the name is not qualified
+func splitName(table string) (owner, name string) {
+	name = table
+	if i := strings.LastIndex(table, "."); i >= 0 {
+		owner, name = table[:i], table[i+1:]
+	}
+	return strings.Trim(owner, `"`), strings.Trim(name, `"`)
+}
+
+//apply applies or reverts the migration in a transaction, recording it
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration *Migration, up bool) error {
+	script, direction := migration.Up, "up"
+	if !up {
+		script, direction = migration.Down, "down"
+	}
+	file := fmt.Sprintf("%d_%s.%s.sql", migration.Version, migration.Name, direction)
+
+	statements, err := sqlsplit.Split(script)
+	if err != nil {
+		return &MigrationError{Version: migration.Version, File: file, Err: err}
+	}
+
+	if m.dryRun != nil {
+		return m.print(file, statements)
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement.SQL); err != nil {
+			tx.Rollback()
+			return &MigrationError{
+				Version:   migration.Version,
+				File:      file,
+				Line:      statement.Line,
+				Statement: statement.SQL,
+				Err:       err,
+			}
+		}
+	}
+
+	if up {
+		_, err = tx.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s (version, name, checksum) VALUES (?, ?, ?)", m.table),
+			migration.Version, migration.Name, migration.Checksum)
+	} else {
+		_, err = tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE version = ?", m.table), migration.Version)
+	}
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("did not record migration %s: %v", file, err)
+	}
+
+	return tx.Commit()
+}
+
+//print writes the statements of a dry run, separated by go lines
+func (m *Migrator) print(file string, statements []sqlsplit.Statement) error {
+	if _, err := fmt.Fprintf(m.dryRun, "-- %s\n", file); err != nil {
+		return err
+	}
+	for _, statement := range statements {
+		if _, err := fmt.Fprintf(m.dryRun, "%s\ngo\n\n", statement.SQL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//lock takes the server side lock, returning a connection on which to migrate while it is held and
+//a func releasing the lock and the connection. A mutex is locked on the connection returned. A table
+//is locked first on a connection of its own, as migrations commit, so the pool must allow two.
+func (m *Migrator) lock(ctx context.Context) (*sql.Conn, func() error, error) {
+	switch m.lockMode {
+	case LockMutex:
+		conn, err := m.db.Conn(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if err := m.createMutex(ctx, conn); err != nil {
+			conn.Close()
+			return nil, nil, err
+		}
+		if err := exec(ctx, conn, "LOCK MUTEX %s IN EXCLUSIVE MODE", m.lockName); err != nil {
+			conn.Close()
+			return nil, nil, fmt.Errorf("did not lock mutex %s: %v", m.lockName, err)
+		}
+
+		return conn, func() error {
+			defer conn.Close()
+
+			//the mutex is held until released or the connection closes, so it is released even
+			//if ctx has finished, and a connection still holding it is closed rather than pooled
+			ctx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+			defer cancel()
+
+			if err := exec(ctx, conn, "RELEASE MUTEX %s", m.lockName); err != nil {
+				conn.Raw(func(interface{}) error {
+					return driver.ErrBadConn
+				})
+				return fmt.Errorf("did not release mutex %s: %v", m.lockName, err)
+			}
+			return nil
+		}, nil
+
+	case LockTable:
+		//waiting for a second connection from a pool of one would never end
+		if m.db.Stats().MaxOpenConnections == 1 {
+			return nil, nil, fmt.Errorf("did not lock table %s: a second connection is needed, but the pool allows one", m.lockName)
+		}
+
+		lockConn, err := m.db.Conn(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if err := exec(ctx, lockConn, "CREATE TABLE IF NOT EXISTS %s (id INT PRIMARY KEY)", m.lockName); err != nil {
+			lockConn.Close()
+			return nil, nil, fmt.Errorf("did not create lock table %s: %v", m.lockName, err)
+		}
+
+		tx, err := lockConn.BeginTx(ctx, nil)
+		if err != nil {
+			lockConn.Close()
+			return nil, nil, err
+		}
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("LOCK TABLE %s IN EXCLUSIVE MODE", m.lockName)); err != nil {
+			tx.Rollback()
+			lockConn.Close()
+			return nil, nil, fmt.Errorf("did not lock table %s: %v", m.lockName, err)
+		}
+
+		conn, err := m.db.Conn(ctx)
+		if err != nil {
+			tx.Rollback()
+			lockConn.Close()
+			return nil, nil, err
+		}
+
+		return conn, func() error {
+			conn.Close()
+			defer lockConn.Close()
+			return tx.Rollback()
+		}, nil
+
+	default:
+		return nil, nil, fmt.Errorf("unknown lock mode %d", m.lockMode)
+	}
+}
+
+//releaseTimeout bounds releasing the mutex, which is done regardless of the context migrating
+const releaseTimeout = 5 * time.Second
+
+//createMutex creates the mutex unless it exists. Concurrent migrators may both try to create it,
+//so failing to create it is an error only if it still does not exist.
+func (m *Migrator) createMutex(ctx context.Context, conn *sql.Conn) error {
+	exists, err := mutexExists(ctx, conn, m.lockName)
+	if err != nil || exists {
+		return err
+	}
+
+	createErr := exec(ctx, conn, "CREATE MUTEX %s SCOPE CONNECTION", m.lockName)
+	if createErr == nil {
+		return nil
+	}
+
+	if exists, err := mutexExists(ctx, conn, m.lockName); err != nil || !exists {
+		return fmt.Errorf("did not create mutex %s: %v", m.lockName, createErr)
+	}
+	return nil
+}
+
+func mutexExists(ctx context.Context, conn *sql.Conn, mutex string) (bool, error) {
+	_, name := splitName(mutex)
+
+	var count int
+	if err := conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM SYS.SYSMUTEXSEMAPHORE WHERE name = ?", name).Scan(&count); err != nil {
+		return false, fmt.Errorf("did not look up mutex %s: %v", mutex, err)
+	}
+	return count > 0, nil
+}
+
+func exec(ctx context.Context, conn *sql.Conn, format string, args ...interface{}) error {
+	_, err := conn.ExecContext(ctx, fmt.Sprintf(format, args...))
+	return err
+}
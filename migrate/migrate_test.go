@@ -0,0 +1,202 @@
+//go:build go1.16
+// +build go1.16
+
+package migrate
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/mdcnz/sqlanywhere/internal/testdb"
+)
+
+var migrations = fstest.MapFS{
+	"1_customer.up.sql":   {Data: []byte("create table customer (id int primary key, name varchar(40));")},
+	"1_customer.down.sql": {Data: []byte("drop table customer;")},
+	"2_rename.up.sql": {Data: []byte(`create procedure rename_customer(in customer_id int, in new_name varchar(40))
+begin
+	update customer set name = new_name where id = customer_id;
+	commit;
+end;
+
+insert into customer values (1, 'first');
+`)},
+	"2_rename.down.sql": {Data: []byte("drop procedure rename_customer;\ndelete from customer;")},
+	"readme.md":         {Data: []byte("not a migration")},
+}
+
+func TestNew(t *testing.T) {
+	m, err := New(nil, migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.Migrations(); len(got) != 2 || got[0].Name != "customer" || got[1].Version != 2 || got[1].Down == "" {
+		t.Fatalf("unexpected migrations %+v", got)
+	}
+
+	for name, fsys := range map[string]fstest.MapFS{
+		"no up":           {"1_a.down.sql": {}},
+		"names differ":    {"1_a.up.sql": {}, "1_b.down.sql": {}},
+		"zero version":    {"0_a.up.sql": {}},
+		"version too big": {"99999999999999999999_a.up.sql": {}},
+	} {
+		if _, err := New(nil, fsys); err == nil {
+			t.Errorf("%s: want error", name)
+		}
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	database := testdb.New(t)
+	defer database.Cleanup()
+
+	db, close := database.Open()
+	defer close()
+
+	ctx := context.Background()
+
+	var dryRun bytes.Buffer
+	m, err := New(db, migrations, WithDryRun(&dryRun))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if done, err := m.Up(ctx); err != nil || len(done) != 2 {
+		t.Fatalf("want 2 migrations in dry run, got %d: %v", len(done), err)
+	}
+	if !strings.Contains(dryRun.String(), "-- 2_rename.up.sql\ncreate procedure rename_customer") {
+		t.Fatalf("unexpected dry run:\n%s", dryRun.String())
+	}
+	if applied, err := m.Applied(ctx); err != nil || len(applied) != 0 {
+		t.Fatalf("want nothing applied by dry run, got %v: %v", applied, err)
+	}
+
+	//concurrent migrators apply each migration once
+	var wg sync.WaitGroup
+	counts := make([]int, 3)
+	errs := make([]error, 3)
+	for i := range counts {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			m, err := New(db, migrations)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			done, err := m.Up(ctx)
+			counts[i], errs[i] = len(done), err
+		}(i)
+	}
+	wg.Wait()
+
+	total := 0
+	for i := range counts {
+		if errs[i] != nil {
+			t.Fatal(errs[i])
+		}
+		total += counts[i]
+	}
+	if total != 2 {
+		t.Fatalf("want 2 migrations applied in total, got %v", counts)
+	}
+
+	if _, err := db.Exec("call rename_customer(1, 'renamed')"); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err = New(db, migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if done, err := m.Down(ctx, 1); err != nil || len(done) != 1 || done[0].Version != 2 {
+		t.Fatalf("want migration 2 reverted, got %v: %v", done, err)
+	}
+	if applied, err := m.Applied(ctx); err != nil || len(applied) != 1 || applied[0].Version != 1 {
+		t.Fatalf("want migration 1 applied, got %v: %v", applied, err)
+	}
+
+	//a changed migration is detected
+	changed := fstest.MapFS{"1_customer.up.sql": {Data: []byte("create table customer (id bigint primary key);")}}
+	m, err = New(db, changed, WithLock(LockTable, "migrate_lock_table"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(ctx); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("want %v, got %v", ErrChecksumMismatch, err)
+	}
+
+	//a failing statement is reported with its line, and the migration not recorded
+	failing := fstest.MapFS{
+		"1_customer.up.sql": migrations["1_customer.up.sql"],
+		"3_bad.up.sql":      {Data: []byte("insert into customer values (2, 'second');\n\ninsert into no_such_table values (1);")},
+	}
+	m, err = New(db, failing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = m.Up(ctx)
+	var migrationErr *MigrationError
+	if !errors.As(err, &migrationErr) || migrationErr.Version != 3 || migrationErr.Line != 3 {
+		t.Fatalf("want error at line 3 of migration 3, got %v", err)
+	}
+	if applied, _ := m.Applied(ctx); len(applied) != 1 {
+		t.Fatalf("want failed migration not recorded, got %v", applied)
+	}
+	var rows int
+	if err := db.QueryRow("select count(*) from customer where id = 2").Scan(&rows); err != nil || rows != 0 {
+		t.Fatalf("want failed migration rolled back, got %d rows: %v", rows, err)
+	}
+}
+
+// TestMigrateOneConnection checks migrating with a pool of one connection, recording migrations in a table qualified by its owner
+func TestMigrateOneConnection(t *testing.T) {
+	database := testdb.New(t)
+	defer database.Cleanup()
+
+	db, close := database.Open()
+	defer close()
+	db.SetMaxOpenConns(1)
+
+	ctx := context.Background()
+
+	var owner string
+	if err := db.QueryRow("select current user").Scan(&owner); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := New(db, migrations, WithTable(owner+".migrations_log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if done, err := m.Up(ctx); err != nil || len(done) != 2 {
+		t.Fatalf("want 2 migrations applied, got %d: %v", len(done), err)
+	}
+	if done, err := m.Up(ctx); err != nil || len(done) != 0 {
+		t.Fatalf("want applied migrations found in qualified table, got %d applied again: %v", len(done), err)
+	}
+
+	m, err = New(db, migrations, WithLock(LockTable, "migrate_lock_table"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(ctx); err == nil || !strings.Contains(err.Error(), "second connection") {
+		t.Fatalf("want error locking a table with one connection, got %v", err)
+	}
+}
+
+func TestSplitName(t *testing.T) {
+	for table, want := range map[string][2]string{
+		"schema_migrations":         {"", "schema_migrations"},
+		"dba.schema_migrations":     {"dba", "schema_migrations"},
+		`"DBA"."schema_migrations"`: {"DBA", "schema_migrations"},
+	} {
+		if owner, name := splitName(table); owner != want[0] || name != want[1] {
+			t.Errorf("%s: want %v, got %s %s", table, want, owner, name)
+		}
+	}
+}
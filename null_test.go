@@ -0,0 +1,141 @@
+package sqlanywhere
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+//nullColumns are the columns of the null_test table, with a value of each supported Go type
+var nullColumns = []struct {
+	name     string
+	datatype string
+	value    interface{}
+}{
+	{"c_int", "bigint", int64(42)},
+	{"c_float", "double", 1.5},
+	{"c_bool", "bit", true},
+	{"c_string", "varchar(20)", "text"},
+	{"c_bytes", "varbinary(20)", []byte{1, 2, 3}},
+	{"c_time", "timestamp", time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)},
+}
+
+func TestNullParameters(t *testing.T) {
+	testdb := NewTestDB(t)
+	defer testdb.Cleanup()
+
+	db, close := testdb.Open()
+	defer close()
+
+	definitions := []string{"id int primary key"}
+	names := []string{"id"}
+	for _, c := range nullColumns {
+		definitions = append(definitions, c.name+" "+c.datatype+" null")
+		names = append(names, c.name)
+	}
+
+	if _, err := db.Exec("create table null_test (" + strings.Join(definitions, ", ") + ")"); err != nil {
+		t.Fatalf("did not create table: %v", err)
+	}
+
+	positional := "insert into null_test (" + strings.Join(names, ", ") + ") values (?" + strings.Repeat(", ?", len(nullColumns)) + ")"
+	named := "insert into null_test (" + strings.Join(names, ", ") + ") values (:" + strings.Join(names, ", :") + ")"
+
+	id := 0
+
+	//NULL in each position, with every other parameter bound to a value
+	for null := range nullColumns {
+		t.Run("positional "+nullColumns[null].name, func(t *testing.T) {
+			id++
+			args := []interface{}{id}
+			for i, c := range nullColumns {
+				if i == null {
+					args = append(args, nil)
+				} else {
+					args = append(args, c.value)
+				}
+			}
+
+			if _, err := db.Exec(positional, args...); err != nil {
+				t.Fatal(err)
+			}
+			checkNullRow(t, db, id, null)
+		})
+
+		t.Run("named reversed "+nullColumns[null].name, func(t *testing.T) {
+			id++
+			var args []interface{}
+			for i := len(nullColumns) - 1; i >= 0; i-- {
+				var value interface{}
+				if i != null {
+					value = nullColumns[i].value
+				}
+				args = append(args, sql.Named(nullColumns[i].name, value))
+			}
+			args = append(args, sql.Named("id", id))
+
+			if _, err := db.Exec(named, args...); err != nil {
+				t.Fatal(err)
+			}
+			checkNullRow(t, db, id, null)
+		})
+	}
+
+	t.Run("all null", func(t *testing.T) {
+		id++
+		args := []interface{}{id}
+		for range nullColumns {
+			args = append(args, nil)
+		}
+
+		if _, err := db.Exec(positional, args...); err != nil {
+			t.Fatal(err)
+		}
+
+		var nulls int
+		query := fmt.Sprintf("select count(*) from null_test where id = ? and %s is null", strings.Join(names[1:], " is null and "))
+		if err := db.QueryRow(query, id).Scan(&nulls); err != nil {
+			t.Fatal(err)
+		}
+		if nulls != 1 {
+			t.Fatal("want a row with every column null")
+		}
+	})
+}
+
+//checkNullRow checks the row with id has NULL in column null, and the column values in the others
+func checkNullRow(t *testing.T, db *sql.DB, id int, null int) {
+	var (
+		i  sql.NullInt64
+		f  sql.NullFloat64
+		b  sql.NullBool
+		s  sql.NullString
+		bs []byte
+		tm sql.NullTime
+	)
+
+	err := db.QueryRow("select c_int, c_float, c_bool, c_string, c_bytes, c_time from null_test where id = ?", id).
+		Scan(&i, &f, &b, &s, &bs, &tm)
+	if err != nil {
+		t.Fatalf("did not select row %d: %v", id, err)
+	}
+
+	valid := []bool{i.Valid, f.Valid, b.Valid, s.Valid, bs != nil, tm.Valid}
+	for column, ok := range valid {
+		if ok == (column == null) {
+			t.Fatalf("column %s: want null %v, got value %v", nullColumns[column].name, column == null, ok)
+		}
+	}
+
+	if null != 0 && i.Int64 != 42 ||
+		null != 1 && f.Float64 != 1.5 ||
+		null != 2 && !b.Bool ||
+		null != 3 && s.String != "text" ||
+		null != 4 && !bytes.Equal(bs, []byte{1, 2, 3}) ||
+		null != 5 && !tm.Time.Equal(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Fatalf("unexpected values in row %d: %v %v %v %v %v %v", id, i, f, b, s, bs, tm)
+	}
+}
@@ -0,0 +1,137 @@
+package sqlanywhere
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"time"
+)
+
+//Option configures a connector returned by NewConnector
+type Option func(*connector)
+
+//NewConnector returns a connector for the given connection string, configured with options.
+//Use it with sql.OpenDB, for example:
+//
+//	connector, err := sqlanywhere.NewConnector(dsn, sqlanywhere.WithHealthCheck(time.Minute))
+//	db := sql.OpenDB(connector)
+func NewConnector(name string, options ...Option) (driver.Connector, error) {
+	c, err := sacapi.openConnector(name)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, option := range options {
+		option(c)
+	}
+
+	return c, nil
+}
+
+//WithHealthCheck pings idle pooled connections every interval, marking those that
+//fail so the pool discards them rather than handing them out. Zero disables the check.
+func WithHealthCheck(interval time.Duration) Option {
+	return func(c *connector) {
+		c.healthCheckInterval = interval
+	}
+}
+
+//WithPingTimeout bounds each ping made by the health checker. The default is 5 seconds.
+func WithPingTimeout(timeout time.Duration) Option {
+	return func(c *connector) {
+		c.pingTimeout = timeout
+	}
+}
+
+//WithResetSQL executes sql each time a pooled connection is reused, after any outstanding
+//transaction is rolled back and driver changed options are restored. Use it to clear
+//connection state such as variables or temporary tables. If it fails the connection is discarded.
+func WithResetSQL(sql string) Option {
+	return func(c *connector) {
+		c.resetSQL = sql
+	}
+}
+
+//WithChainedMode leaves autocommit off outside of transactions, so statements executed
+//outside Begin are not committed until a COMMIT statement is executed on the same connection.
+//By default each statement outside a transaction is committed as it executes.
+func WithChainedMode() Option {
+	return func(c *connector) {
+		c.chained = true
+	}
+}
+
+//WithCancelStrategy sets how requests are interrupted when their context finishes.
+//The default is CancelWithCallback.
+func WithCancelStrategy(strategy CancelStrategy) Option {
+	return func(c *connector) {
+		c.cancelStrategy = strategy
+	}
+}
+
+//WithDefaultIsolation sets the isolation level of new connections, used by transactions begun
+//with sql.LevelDefault. By default the database's isolation_level option is left unchanged.
+func WithDefaultIsolation(level sql.IsolationLevel) Option {
+	return func(c *connector) {
+		c.defaultIsolation = level
+	}
+}
+
+//WithStatementCache caches up to size prepared statements per connection, reused by queries
+//with arguments executed directly on a sql.DB, sql.Conn or sql.Tx. The size is limited to half
+//of the server's max_statement_count option. Zero disables the cache, the default.
+func WithStatementCache(size int) Option {
+	return func(c *connector) {
+		c.statementCacheSize = size
+	}
+}
+
+//WithTracer reports driver operations to the tracer: connecting, preparing, executing, querying,
+//fetching rows in batches, beginning, committing and rolling back transactions, and closing.
+func WithTracer(tracer Tracer) Option {
+	return func(c *connector) {
+		c.tracer = tracer
+	}
+}
+
+//WithSlowQueryThreshold calls report for each statement executed or queried directly on a sql.DB,
+//sql.Conn or sql.Tx that takes at least threshold. Argument values are not reported.
+func WithSlowQueryThreshold(threshold time.Duration, report func(SlowQuery)) Option {
+	return func(c *connector) {
+		c.slowQueryThreshold = threshold
+		c.slowQueryReport = report
+	}
+}
+
+//WithSlowQueryPlan adds the server's plan to each slow query reported, obtained on the same
+//connection after the statement, before it returns. Planning is bounded by timeout, after which
+//the report has the timeout as its PlanErr; zero uses a default of 1 second. Planning delays only
+//slow statements, but still choose a threshold that is rarely exceeded.
+func WithSlowQueryPlan(mode ExplainMode, timeout time.Duration) Option {
+	return func(c *connector) {
+		if timeout <= 0 {
+			timeout = defaultSlowQueryPlanTimeout
+		}
+		c.slowQueryPlan = true
+		c.slowQueryPlanMode = mode
+		c.slowQueryPlanTimeout = timeout
+	}
+}
+
+//WithFileTransferPolicy registers a file transfer validation callback, consulted when a statement
+//executed indirectly, such as from a procedure, reads or writes a client file with LOAD TABLE,
+//UNLOAD, READ_CLIENT_FILE or WRITE_CLIENT_FILE. Without a policy such transfers are denied.
+//Statements executed directly, as by LoadTable and UnloadQuery, do not consult the policy.
+func WithFileTransferPolicy(policy FileTransferPolicy) Option {
+	return func(c *connector) {
+		c.fileTransferPolicy = policy
+	}
+}
+
+//WithMessageHandler calls handler with each message the server sends to the client while executing
+//a statement, such as by MESSAGE ... TO CLIENT, or the progress of LOAD TABLE. The handler is called
+//on the goroutine executing the statement, and must not use the connection.
+func WithMessageHandler(handler func(Message)) Option {
+	return func(c *connector) {
+		c.messageHandler = handler
+	}
+}
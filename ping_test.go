@@ -0,0 +1,156 @@
+package sqlanywhere
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestPing(t *testing.T) {
+	testdb := NewTestDB(t)
+	defer testdb.Cleanup()
+
+	db, close := testdb.Open()
+	defer close()
+
+	t.Run("round trip", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		if err := db.PingContext(ctx); err != nil {
+			t.Fatalf("did not ping: %v", err)
+		}
+	})
+
+	t.Run("expired context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		if err := db.PingContext(ctx); err == nil {
+			t.Fatal("pinged with a cancelled context")
+		}
+	})
+
+	t.Run("dropped connection", func(t *testing.T) {
+		ctx := context.Background()
+
+		conn, err := db.Conn(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+
+		dropConnection(t, db, connectionNumber(t, conn))
+
+		if err := conn.PingContext(ctx); err == nil {
+			t.Fatal("pinged a dropped connection")
+		}
+	})
+
+	t.Run("health check", func(t *testing.T) {
+		testHealthCheck(t, testdb, db)
+	})
+
+	t.Run("health check idle", func(t *testing.T) {
+		testHealthCheckIdle(t, testdb)
+	})
+}
+
+//testHealthCheckIdle checks pinging a healthy idle connection leaves it usable by the pool
+func testHealthCheckIdle(t *testing.T, testdb *TestDatabase) {
+	const interval = 50 * time.Millisecond
+
+	connector, err := NewConnector(testdb.ConnectionString(), WithHealthCheck(interval), WithPingTimeout(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := sql.OpenDB(connector)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	ctx := context.Background()
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := connectionNumber(t, conn)
+	conn.Close()
+
+	//several checks of the idle connection, each of which must release it
+	time.Sleep(5 * interval)
+
+	//a deadlocked checker would block the pool, so wait for the connection with a timeout
+	done := make(chan error, 1)
+	var second int
+	go func() {
+		done <- db.QueryRow("SELECT CONNECTION_PROPERTY('Number')").Scan(&second)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+		if second != first {
+			t.Fatalf("want healthy connection %d reused, got %d", first, second)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("health check did not release the idle connection")
+	}
+}
+
+func testHealthCheck(t *testing.T, testdb *TestDatabase, admin *sql.DB) {
+	const interval = 100 * time.Millisecond
+
+	connector, err := NewConnector(testdb.ConnectionString(), WithHealthCheck(interval), WithPingTimeout(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := sql.OpenDB(connector)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	ctx := context.Background()
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := connectionNumber(t, conn)
+	conn.Close() //return the connection to the pool, where it is idle
+
+	dropConnection(t, admin, first)
+
+	time.Sleep(3 * interval)
+
+	//the health checker marked the dropped connection invalid, so the pool opens a new one
+	conn, err = db.Conn(ctx)
+	if err != nil {
+		t.Fatalf("did not get a connection after health check: %v", err)
+	}
+	defer conn.Close()
+
+	if second := connectionNumber(t, conn); second == first {
+		t.Fatalf("pool reused dropped connection %d", first)
+	}
+}
+
+func connectionNumber(t *testing.T, conn *sql.Conn) int {
+	var number int
+	err := conn.QueryRowContext(context.Background(), "SELECT CONNECTION_PROPERTY('Number')").Scan(&number)
+	if err != nil {
+		t.Fatalf("did not read connection number: %v", err)
+	}
+	return number
+}
+
+func dropConnection(t *testing.T, db *sql.DB, number int) {
+	if _, err := db.Exec(fmt.Sprintf("DROP CONNECTION %d", number)); err != nil {
+		t.Fatalf("did not drop connection %d: %v", number, err)
+	}
+}
@@ -0,0 +1,106 @@
+package sqlanywhere
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+)
+
+func TestResetSession(t *testing.T) {
+	testdb := NewTestDB(t)
+	defer testdb.Cleanup()
+
+	connector, err := NewConnector(testdb.ConnectionString(), WithResetSQL("DROP VARIABLE IF EXISTS leaked"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	//a single connection, so each checkout reuses the connection used by the last
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec("create table reset_test (id int primary key)"); err != nil {
+		t.Fatalf("did not create table: %v", err)
+	}
+
+	t.Run("outstanding transaction", func(t *testing.T) {
+		withRawConnection(t, db, func(con *connection) {
+			if _, err := con.BeginTx(context.Background(), driver.TxOptions{}); err != nil {
+				t.Fatal(err)
+			}
+			if err := con.execImmediate("insert into reset_test values (1)"); err != nil {
+				t.Fatal(err)
+			}
+			//leak the transaction: neither committed nor rolled back
+		})
+
+		var count int
+		if err := db.QueryRow("select count(*) from reset_test").Scan(&count); err != nil {
+			t.Fatal(err)
+		}
+		if count != 0 {
+			t.Fatalf("want outstanding insert rolled back, got %d rows", count)
+		}
+	})
+
+	t.Run("driver changed option", func(t *testing.T) {
+		var want string
+		if err := db.QueryRow("select connection_property('isolation_level')").Scan(&want); err != nil {
+			t.Fatal(err)
+		}
+
+		withRawConnection(t, db, func(con *connection) {
+			if _, err := con.currentIsolation(); err != nil {
+				t.Fatal(err)
+			}
+			if err := con.applyIsolation(isolationLevels[sql.LevelSerializable]); err != nil {
+				t.Fatal(err)
+			}
+			//leak the option: as though restoring it after a transaction had failed
+		})
+
+		var got string
+		if err := db.QueryRow("select connection_property('isolation_level')").Scan(&got); err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Fatalf("want isolation level restored to %q, got %q", want, got)
+		}
+	})
+
+	t.Run("reset sql", func(t *testing.T) {
+		withRawConnection(t, db, func(con *connection) {
+			if err := con.execImmediate("CREATE VARIABLE leaked INT"); err != nil {
+				t.Fatal(err)
+			}
+		})
+
+		var exists int
+		if err := db.QueryRow("select varexists('leaked')").Scan(&exists); err != nil {
+			t.Fatal(err)
+		}
+		if exists != 0 {
+			t.Fatal("want variable dropped by reset sql")
+		}
+	})
+}
+
+//withRawConnection runs fn with a pooled driver connection, returning it to the pool afterwards
+func withRawConnection(t *testing.T, db *sql.DB, fn func(con *connection)) {
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	err = conn.Raw(func(driverConn interface{}) error {
+		fn(driverConn.(*connection))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -1,26 +1,25 @@
 package sqlanywhere
 
 type result struct {
-	stmt *statement
-	con  *connection
-}
-
-func (r *result) connection() *connection {
-	if r.stmt != nil && r.stmt.con != nil {
-		return r.stmt.con
-	}
+	con          *connection
+	rowsAffected int64
 
-	if r.con != nil {
-		return r.con
-	}
-
-	return nil
+	//lastInsertID is fetched once, when first asked for
+	lastInsertID *int64
 }
 
 func (r *result) LastInsertId() (int64, error) {
-	return r.connection().queryInt("select @@identity")
+	if r.lastInsertID == nil {
+		id, err := r.con.queryInt("select @@identity")
+		if err != nil {
+			return -1, err
+		}
+		r.lastInsertID = &id
+	}
+
+	return *r.lastInsertID, nil
 }
 
 func (r *result) RowsAffected() (int64, error) {
-	return r.connection().queryInt("select @@rowcount")
+	return r.rowsAffected, nil
 }
@@ -51,3 +51,45 @@ func testLastInsertID(db *sql.DB, t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func testBigLastInsertID(db *sql.DB, t *testing.T) {
+	if _, err := db.Exec(`
+	create table big_person(
+		person_id bigint primary key default autoincrement,
+		person_name varchar(100)
+	)
+	`); err != nil {
+		t.Fatalf("did not create big_person table: %v", err)
+	}
+
+	//autoincrement continues from the largest value, beyond 32 bits
+	if _, err := db.Exec(`insert into big_person values(5000000000, 'First')`); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := db.Exec(`insert into big_person (person_name) values('Second')`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var want int64 = 5000000001
+	if got, err := result.LastInsertId(); err != nil || got != want {
+		t.Fatalf("want LastInsertId %d, got %d: %v", want, got, err)
+	}
+
+	result, err = db.Exec(`update big_person set person_name = 'Updated'`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := result.RowsAffected(); err != nil || n != 2 {
+		t.Fatalf("want 2 rows affected, got %d: %v", n, err)
+	}
+
+	result, err = db.Exec(`delete from big_person where person_name = ?`, "Updated")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := result.RowsAffected(); err != nil || n != 2 {
+		t.Fatalf("want 2 rows deleted, got %d: %v", n, err)
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"reflect"
+	"sync/atomic"
 	"time"
 	"unsafe"
 )
@@ -17,6 +19,10 @@ type rows struct {
 	columns []*C.a_sqlany_column_info
 	names   []string
 	ctx     context.Context
+
+	//fetching is the trace of the batch of rows being fetched, and fetched the number of rows in it
+	fetching *trace
+	fetched  int64
 }
 
 func (r *rows) Columns() []string {
@@ -29,10 +35,14 @@ func (r *rows) Close() error {
 		return nil
 	}
 
+	if r.fetching != nil {
+		r.endFetch(nil)
+	}
+
 	r.stmt.reset()
 
 	if r.stmt.closeStatementOnRowsClose {
-		r.stmt.Close()
+		r.stmt.release(nil)
 	}
 	return nil
 }
@@ -43,11 +53,25 @@ func (r *rows) Next(dest []driver.Value) error {
 		return errors.New("can't run next, because we don't have a context")
 	}
 
+	if r.fetching == nil {
+		r.fetching = r.stmt.con.startTrace(r.ctx, OpFetch, r.stmt.query, 0)
+	}
+
 	err := r.stmt.con.awaitFunc(r.ctx, r.fetch)
 	if err != nil {
+		if err == io.EOF {
+			r.endFetch(nil)
+		} else {
+			r.endFetch(err)
+		}
 		return err
 	}
 
+	r.fetched++
+	if r.fetched == fetchBatch {
+		r.endFetch(nil)
+	}
+
 	for i := 0; i < len(dest); i++ {
 		if err := r.column(i, &dest[i]); err != nil {
 			return err
@@ -56,6 +80,13 @@ func (r *rows) Next(dest []driver.Value) error {
 	return nil
 }
 
+//endFetch reports the batch of rows fetched
+func (r *rows) endFetch(err error) {
+	r.fetching.end(r.fetched, err)
+	r.fetching = nil
+	r.fetched = 0
+}
+
 func (r *rows) fetch() error {
 
 	if C.sqlany_fetch_next(r.stmt.ptr) == 1 {
@@ -102,6 +133,7 @@ func (r *rows) column(i int, v *driver.Value) error {
 	if value.buffer == nil {
 		return fmt.Errorf("value buffer is nil at index %v", i)
 	}
+	atomic.AddUint64(&stats.bytesFetched, uint64(*value.length))
 
 	var err error
 
@@ -128,7 +160,7 @@ func (r *rows) column(i int, v *driver.Value) error {
 		*v = *(*uint64)(unsafe.Pointer(value.buffer))
 	case C.DT_BIT:
 		*v = *(*bool)(unsafe.Pointer(value.buffer))
-	case C.DT_VARCHAR, C.DT_FIXCHAR, C.DT_LONGVARCHAR, C.DT_STRING, C.DT_LONGNVARCHAR:
+	case C.DT_VARCHAR, C.DT_FIXCHAR, C.DT_LONGVARCHAR, C.DT_STRING, C.DT_NSTRING, C.DT_NFIXCHAR, C.DT_NVARCHAR, C.DT_LONGNVARCHAR:
 		*v = C.GoStringN(value.buffer, C.int(*value.length))
 	case C.DT_BINARY, C.DT_LONGBINARY:
 		//const MaxBlobSize = 1<<31 - 1 // 2,147,483,647 bytes
@@ -153,6 +185,67 @@ func (r *rows) column(i int, v *driver.Value) error {
 	return err
 }
 
+//nativeTypes are the database type names and the Go types of the values of each native type
+var nativeTypes = map[C.a_sqlany_native_type]struct {
+	name     string
+	scanType reflect.Type
+}{
+	C.DT_DATE:         {"DATE", reflect.TypeOf(time.Time{})},
+	C.DT_TIME:         {"TIME", reflect.TypeOf(time.Time{})},
+	C.DT_TIMESTAMP:    {"TIMESTAMP", reflect.TypeOf(time.Time{})},
+	C.DT_VARCHAR:      {"VARCHAR", reflect.TypeOf("")},
+	C.DT_FIXCHAR:      {"CHAR", reflect.TypeOf("")},
+	C.DT_LONGVARCHAR:  {"LONG VARCHAR", reflect.TypeOf("")},
+	C.DT_STRING:       {"VARCHAR", reflect.TypeOf("")},
+	C.DT_DOUBLE:       {"DOUBLE", reflect.TypeOf(float64(0))},
+	C.DT_FLOAT:        {"REAL", reflect.TypeOf(float32(0))},
+	C.DT_DECIMAL:      {"DECIMAL", reflect.TypeOf("")},
+	C.DT_INT:          {"INTEGER", reflect.TypeOf(int32(0))},
+	C.DT_SMALLINT:     {"SMALLINT", reflect.TypeOf(int16(0))},
+	C.DT_BINARY:       {"BINARY", reflect.TypeOf([]byte(nil))},
+	C.DT_LONGBINARY:   {"LONG BINARY", reflect.TypeOf([]byte(nil))},
+	C.DT_TINYINT:      {"TINYINT", reflect.TypeOf(uint8(0))},
+	C.DT_BIGINT:       {"BIGINT", reflect.TypeOf(int64(0))},
+	C.DT_UNSINT:       {"UNSIGNED INT", reflect.TypeOf(uint32(0))},
+	C.DT_UNSSMALLINT:  {"UNSIGNED SMALLINT", reflect.TypeOf(uint16(0))},
+	C.DT_UNSBIGINT:    {"UNSIGNED BIGINT", reflect.TypeOf(uint64(0))},
+	C.DT_BIT:          {"BIT", reflect.TypeOf(false)},
+	C.DT_NSTRING:      {"NVARCHAR", reflect.TypeOf("")},
+	C.DT_NFIXCHAR:     {"NCHAR", reflect.TypeOf("")},
+	C.DT_NVARCHAR:     {"NVARCHAR", reflect.TypeOf("")},
+	C.DT_LONGNVARCHAR: {"LONG NVARCHAR", reflect.TypeOf("")},
+}
+
+//ColumnTypeDatabaseTypeName returns the name of the column's native type, such as DECIMAL or LONG VARCHAR,
+//implementing driver.RowsColumnTypeDatabaseTypeName. DECIMAL includes NUMERIC and MONEY columns.
+func (r *rows) ColumnTypeDatabaseTypeName(index int) string {
+	return nativeTypes[r.columns[index].native_type].name
+}
+
+//ColumnTypeNullable reports whether the column may be NULL, implementing driver.RowsColumnTypeNullable
+func (r *rows) ColumnTypeNullable(index int) (nullable, ok bool) {
+	return r.columns[index].nullable != 0, true
+}
+
+//ColumnTypePrecisionScale returns the precision and scale of DECIMAL columns,
+//implementing driver.RowsColumnTypePrecisionScale
+func (r *rows) ColumnTypePrecisionScale(index int) (precision, scale int64, ok bool) {
+	column := r.columns[index]
+	if column.native_type != C.DT_DECIMAL {
+		return 0, 0, false
+	}
+	return int64(column.precision), int64(column.scale), true
+}
+
+//ColumnTypeScanType returns the Go type of the column's values, implementing driver.RowsColumnTypeScanType.
+//DECIMAL values are strings, so they are not rounded.
+func (r *rows) ColumnTypeScanType(index int) reflect.Type {
+	if t, ok := nativeTypes[r.columns[index].native_type]; ok {
+		return t.scanType
+	}
+	return reflect.TypeOf(new(interface{})).Elem()
+}
+
 // HasNextResultSet is called at the end of the current result set and
 // reports whether there is another result set after the current one.
 func (r *rows) HasNextResultSet() bool {
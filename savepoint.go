@@ -0,0 +1,108 @@
+package sqlanywhere
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+//ErrSavepointDone is returned when rolling back to or releasing a savepoint already rolled back to or released
+var ErrSavepointDone = errors.New("savepoint has already been rolled back or released")
+
+//SavepointHandle is a savepoint within a transaction. Rolling back to it undoes the work done since it
+//was created, without aborting the transaction.
+type SavepointHandle struct {
+	tx   *sql.Tx
+	name string
+	done bool
+}
+
+//lastSavepoint numbers the savepoints created without a name
+var lastSavepoint uint64
+
+//Savepoint creates a savepoint with the given name in the transaction. An empty name creates a unique name.
+func Savepoint(ctx context.Context, tx *sql.Tx, name string) (*SavepointHandle, error) {
+	if name == "" {
+		name = fmt.Sprintf("go_savepoint_%d", atomic.AddUint64(&lastSavepoint, 1))
+	}
+
+	if !isIdentifier(name) {
+		return nil, fmt.Errorf("invalid savepoint name %q", name)
+	}
+
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return nil, fmt.Errorf("did not create savepoint %s: %v", name, err)
+	}
+
+	return &SavepointHandle{tx: tx, name: name}, nil
+}
+
+//Name returns the name of the savepoint
+func (sp *SavepointHandle) Name() string {
+	return sp.name
+}
+
+//Rollback undoes the work done in the transaction since the savepoint was created
+func (sp *SavepointHandle) Rollback(ctx context.Context) error {
+	return sp.end(ctx, "ROLLBACK TO SAVEPOINT ")
+}
+
+//Release keeps the work done since the savepoint was created as part of the transaction
+func (sp *SavepointHandle) Release(ctx context.Context) error {
+	return sp.end(ctx, "RELEASE SAVEPOINT ")
+}
+
+func (sp *SavepointHandle) end(ctx context.Context, statement string) error {
+	if sp.done {
+		return ErrSavepointDone
+	}
+
+	if _, err := sp.tx.ExecContext(ctx, statement+sp.name); err != nil {
+		return fmt.Errorf("did not %s%s: %v", statement, sp.name, err)
+	}
+
+	sp.done = true
+	return nil
+}
+
+//RunInSavepoint runs fn within a savepoint of the transaction, rolling back to the savepoint if fn
+//returns an error or panics, and releasing it otherwise. Calls may be nested within fn to compose
+//units of work that partially roll back. An empty name creates a unique name.
+func RunInSavepoint(ctx context.Context, tx *sql.Tx, name string, fn func(ctx context.Context) error) error {
+	sp, err := Savepoint(ctx, tx, name)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			sp.Rollback(ctx)
+			panic(p)
+		}
+	}()
+
+	if err := fn(ctx); err != nil {
+		if rollbackErr := sp.Rollback(ctx); rollbackErr != nil {
+			return fmt.Errorf("%v: %v", err, rollbackErr)
+		}
+		return err
+	}
+
+	return sp.Release(ctx)
+}
+
+//isIdentifier reports whether name can be used unquoted as a SQL identifier
+func isIdentifier(name string) bool {
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		return false
+	}
+
+	for _, c := range name {
+		if !isArg(c) {
+			return false
+		}
+	}
+	return true
+}
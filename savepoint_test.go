@@ -0,0 +1,113 @@
+package sqlanywhere
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSavepoint(t *testing.T) {
+	testdb := NewTestDB(t)
+	defer testdb.Cleanup()
+
+	db, close := testdb.Open()
+	defer close()
+
+	if _, err := db.Exec("create table savepoint_test (id int primary key)"); err != nil {
+		t.Fatalf("did not create table: %v", err)
+	}
+
+	ctx := context.Background()
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	insert := func(id int) func(ctx context.Context) error {
+		return func(ctx context.Context) error {
+			_, err := tx.ExecContext(ctx, "insert into savepoint_test values (?)", id)
+			return err
+		}
+	}
+
+	if err := insert(1)(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	failure := errors.New("failure")
+
+	//rolled back to the savepoint, keeping the row inserted before it
+	err = RunInSavepoint(ctx, tx, "first", func(ctx context.Context) error {
+		if err := insert(2)(ctx); err != nil {
+			return err
+		}
+		return failure
+	})
+	if err != failure {
+		t.Fatalf("want %v, got %v", failure, err)
+	}
+
+	//nested: the inner savepoint rolls back, the outer is released
+	err = RunInSavepoint(ctx, tx, "", func(ctx context.Context) error {
+		if err := insert(3)(ctx); err != nil {
+			return err
+		}
+
+		inner := RunInSavepoint(ctx, tx, "", func(ctx context.Context) error {
+			if err := insert(4)(ctx); err != nil {
+				return err
+			}
+			return failure
+		})
+		if inner != failure {
+			t.Fatalf("want inner %v, got %v", failure, inner)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sp, err := Savepoint(ctx, tx, "last")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sp.Release(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := sp.Rollback(ctx); err != ErrSavepointDone {
+		t.Fatalf("want %v, got %v", ErrSavepointDone, err)
+	}
+
+	if _, err := Savepoint(ctx, tx, "bad name"); err == nil {
+		t.Fatal("created savepoint with invalid name")
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := db.Query("select id from savepoint_test order by id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ids) != 2 || ids[0] != 1 || ids[1] != 3 {
+		t.Fatalf("want ids [1 3], got %v", ids)
+	}
+}
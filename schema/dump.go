@@ -0,0 +1,372 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+//DumpOptions select the objects and form of the DDL written by Dump
+type DumpOptions struct {
+	Options
+
+	//Users adds CREATE USER statements for the owners and grantees, other than builtin users
+	Users bool
+
+	//Permissions adds GRANT statements for the privileges on tables, views and procedures
+	Permissions bool
+
+	//Delimiter is the line written after each statement. Empty is "go", as written by dbunload
+	//and understood by dbisql, since procedure bodies contain semicolons.
+	Delimiter string
+}
+
+//builtinUsers exist in every database, with the SystemOwners and the SYS_ roles, so are never created by Dump
+var builtinUsers = []string{"DBA", "PUBLIC"}
+
+func isBuiltinUser(name string) bool {
+	if strings.HasPrefix(strings.ToUpper(name), "SYS_") {
+		return true
+	}
+	for _, builtin := range append(builtinUsers, SystemOwners...) {
+		if strings.EqualFold(name, builtin) {
+			return true
+		}
+	}
+	return false
+}
+
+//Dump writes the DDL creating the objects of the database, in an order that they can be created in.
+//The output is deterministic, so can be compared with a previous dump to find schema changes.
+//The order is users, sequences, tables, indexes, foreign keys, procedures and functions, views, triggers and grants.
+func Dump(ctx context.Context, q Querier, w io.Writer, opts *DumpOptions) error {
+	if opts == nil {
+		opts = &DumpOptions{}
+	}
+
+	catalog, err := Load(ctx, q, &opts.Options)
+	if err != nil {
+		return err
+	}
+
+	d := &dumper{w: w, delimiter: opts.Delimiter}
+	if d.delimiter == "" {
+		d.delimiter = "go"
+	}
+
+	if opts.Users {
+		d.users(catalog)
+	}
+	for _, s := range catalog.Sequences {
+		d.sequence(s)
+	}
+	for _, t := range catalog.Tables {
+		d.table(t)
+	}
+	for _, t := range catalog.Tables {
+		for _, i := range t.Indexes {
+			if !i.Constraint {
+				d.index(t, i)
+			}
+		}
+	}
+	for _, t := range catalog.Tables {
+		for _, k := range t.ForeignKeys {
+			d.foreignKey(t, k)
+		}
+	}
+	//functions first, since views may call them; procedures are not checked until called
+	for _, function := range []bool{true, false} {
+		for _, p := range catalog.Procedures {
+			if p.IsFunction() == function {
+				d.definition(p.Definition)
+			}
+		}
+	}
+	for _, v := range orderViews(catalog.Views) {
+		d.view(v)
+	}
+	for _, t := range catalog.Tables {
+		for _, tr := range t.Triggers {
+			d.definition(tr.Definition)
+		}
+	}
+	if opts.Permissions {
+		d.grants(catalog)
+	}
+
+	return d.err
+}
+
+//dumper writes statements, keeping the first error
+type dumper struct {
+	w         io.Writer
+	delimiter string
+	err       error
+}
+
+//statement writes a statement and the delimiter
+func (d *dumper) statement(format string, args ...interface{}) {
+	if d.err != nil {
+		return
+	}
+	_, d.err = fmt.Fprintf(d.w, format+"\n%s\n\n", append(args, d.delimiter)...)
+}
+
+//definition writes a CREATE statement stored by the server, if any
+func (d *dumper) definition(definition string) {
+	if definition = strings.TrimSpace(definition); definition != "" {
+		d.statement("%s", definition)
+	}
+}
+
+func (d *dumper) users(catalog *Catalog) {
+	names := make(map[string]bool)
+	add := func(name string) {
+		if !isBuiltinUser(name) {
+			names[name] = true
+		}
+	}
+
+	for _, u := range catalog.Users {
+		add(u.Name)
+	}
+	for _, g := range allGrants(catalog) {
+		add(g.grant.Grantee)
+	}
+
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	for _, name := range sorted {
+		d.statement("CREATE USER %s", quote(name))
+	}
+}
+
+func (d *dumper) sequence(s *Sequence) {
+	cycle := "NO CYCLE"
+	if s.Cycle {
+		cycle = "CYCLE"
+	}
+	cache := "NO CACHE"
+	if s.Cache > 0 {
+		cache = fmt.Sprintf("CACHE %d", s.Cache)
+	}
+
+	d.statement("CREATE SEQUENCE %s.%s START WITH %d INCREMENT BY %d MINVALUE %d MAXVALUE %d %s %s",
+		quote(s.Owner), quote(s.Name), s.StartWith, s.Increment, s.MinValue, s.MaxValue, cache, cycle)
+}
+
+func (d *dumper) table(t *Table) {
+	var lines []string
+	for _, c := range t.Columns {
+		definition := columnDefinition(c)
+		for _, check := range t.Checks {
+			if strings.EqualFold(check.Column, c.Name) {
+				definition += " " + checkConstraint(check)
+			}
+		}
+		lines = append(lines, "\t"+definition)
+	}
+	if t.PrimaryKey != nil {
+		lines = append(lines, fmt.Sprintf("\tPRIMARY KEY (%s)", indexColumns(t.PrimaryKey)))
+	}
+	for _, i := range t.Indexes {
+		if i.Constraint {
+			lines = append(lines, fmt.Sprintf("\tCONSTRAINT %s UNIQUE (%s)", quote(i.Name), indexColumns(i)))
+		}
+	}
+	for _, check := range t.Checks {
+		if check.Column == "" {
+			lines = append(lines, "\t"+checkConstraint(check))
+		}
+	}
+
+	create, onCommit := "CREATE TABLE", ""
+	if t.Type == "GBL TEMP" {
+		create = "CREATE GLOBAL TEMPORARY TABLE"
+		if t.OnCommit != "" {
+			onCommit = " " + t.OnCommit
+		}
+	}
+
+	d.statement("%s %s.%s (\n%s\n)%s", create, quote(t.Owner), quote(t.Name), strings.Join(lines, ",\n"), onCommit)
+}
+
+func columnDefinition(c *Column) string {
+	definition := quote(c.Name) + " " + c.Type
+
+	if c.Computed {
+		return definition + " COMPUTE (" + c.Default + ")"
+	}
+
+	if c.Nullable {
+		definition += " NULL"
+	} else {
+		definition += " NOT NULL"
+	}
+	if c.Default != "" {
+		definition += " DEFAULT " + c.Default
+	}
+	return definition
+}
+
+func checkConstraint(c *Check) string {
+	return fmt.Sprintf("CONSTRAINT %s CHECK (%s)", quote(c.Name), c.Condition)
+}
+
+func indexColumns(i *Index) string {
+	columns := make([]string, len(i.Columns))
+	for n, c := range i.Columns {
+		columns[n] = quote(c.Name)
+		if c.Descending {
+			columns[n] += " DESC"
+		}
+	}
+	return strings.Join(columns, ", ")
+}
+
+func (d *dumper) index(t *Table, i *Index) {
+	unique := ""
+	if i.Unique {
+		unique = "UNIQUE "
+	}
+	d.statement("CREATE %sINDEX %s ON %s.%s (%s)", unique, quote(i.Name), quote(t.Owner), quote(t.Name), indexColumns(i))
+}
+
+func (d *dumper) foreignKey(t *Table, k *ForeignKey) {
+	statement := fmt.Sprintf("ALTER TABLE %s.%s ADD CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s.%s (%s)",
+		quote(t.Owner), quote(t.Name), quote(k.Name), quoteAll(k.Columns),
+		quote(k.ReferencedOwner), quote(k.ReferencedTable), quoteAll(k.ReferencedColumns))
+
+	if k.OnUpdate != "" {
+		statement += " ON UPDATE " + k.OnUpdate
+	}
+	if k.OnDelete != "" {
+		statement += " ON DELETE " + k.OnDelete
+	}
+	if k.CheckOnCommit {
+		statement += " CHECK ON COMMIT"
+	}
+
+	d.statement("%s", statement)
+}
+
+func (d *dumper) view(v *View) {
+	definition := strings.TrimSpace(v.Definition)
+	if definition == "" {
+		return
+	}
+
+	//the server may store the query alone, rather than the CREATE statement
+	if !strings.HasPrefix(strings.ToLower(definition), "create") {
+		create := "CREATE VIEW"
+		if v.Type == "MAT VIEW" {
+			create = "CREATE MATERIALIZED VIEW"
+		}
+		definition = fmt.Sprintf("%s %s.%s AS %s", create, quote(v.Owner), quote(v.Name), definition)
+	}
+	d.statement("%s", definition)
+}
+
+//objectGrant is a grant with the object it is on
+type objectGrant struct {
+	object string
+	grant  *Grant
+}
+
+//allGrants returns the grants on tables, views and procedures, in the order of the catalog
+func allGrants(catalog *Catalog) []objectGrant {
+	var grants []objectGrant
+	for _, t := range catalog.Tables {
+		for _, g := range t.Grants {
+			grants = append(grants, objectGrant{quote(t.Owner) + "." + quote(t.Name), g})
+		}
+	}
+	for _, v := range catalog.Views {
+		for _, g := range v.Grants {
+			grants = append(grants, objectGrant{quote(v.Owner) + "." + quote(v.Name), g})
+		}
+	}
+	for _, p := range catalog.Procedures {
+		for _, g := range p.Grants {
+			grants = append(grants, objectGrant{quote(p.Owner) + "." + quote(p.Name), g})
+		}
+	}
+	return grants
+}
+
+func (d *dumper) grants(catalog *Catalog) {
+	for _, g := range allGrants(catalog) {
+		var plain []string
+		for _, privilege := range g.grant.Privileges {
+			if !contains(g.grant.WithGrantOption, privilege) {
+				plain = append(plain, privilege)
+			}
+		}
+
+		if len(plain) > 0 {
+			d.statement("GRANT %s ON %s TO %s", strings.Join(plain, ", "), g.object, quote(g.grant.Grantee))
+		}
+		if len(g.grant.WithGrantOption) > 0 {
+			d.statement("GRANT %s ON %s TO %s WITH GRANT OPTION", strings.Join(g.grant.WithGrantOption, ", "), g.object, quote(g.grant.Grantee))
+		}
+	}
+}
+
+//orderViews orders views so each follows the views it depends on, otherwise keeping their order
+func orderViews(views []*View) []*View {
+	byName := make(map[string]*View, len(views))
+	for _, v := range views {
+		byName[v.QualifiedName()] = v
+	}
+
+	ordered := make([]*View, 0, len(views))
+	visited := make(map[*View]bool, len(views))
+
+	var visit func(v *View)
+	visit = func(v *View) {
+		if visited[v] {
+			return
+		}
+		visited[v] = true
+		for _, name := range v.DependsOn {
+			if dependency, ok := byName[name]; ok {
+				visit(dependency)
+			}
+		}
+		ordered = append(ordered, v)
+	}
+
+	for _, v := range views {
+		visit(v)
+	}
+	return ordered
+}
+
+//quote quotes an identifier
+func quote(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func quoteAll(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = quote(name)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
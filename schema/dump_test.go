@@ -0,0 +1,173 @@
+package schema
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mdcnz/sqlanywhere/internal/testdb"
+)
+
+func TestDump(t *testing.T) {
+	source := testdb.New(t)
+	defer source.Cleanup()
+
+	db, close := source.Open()
+	defer close()
+
+	source.Exec(db,
+		`create user reader`,
+		`create sequence order_number start with 1000 increment by 10`,
+		`create table customer (id int primary key, name varchar(40) not null, unique (name))`,
+		`create table orders (
+			id int primary key default autoincrement,
+			customer_id int not null references customer (id) on delete cascade,
+			amount numeric(10, 2) null default 0
+		)`,
+		`create index ix_amount on orders (amount desc)`,
+		`create table product (
+			id int primary key,
+			price numeric(5, 2) null check (price >= 0),
+			quantity int not null,
+			constraint positive_quantity check (quantity > 0 or (quantity = 0 and price is null))
+		)`,
+		`create materialized view product_prices as select id, price from product`,
+		`create global temporary table cart (id int primary key) on commit preserve rows`,
+		`create global temporary table scratch (id int)`,
+		`create function double_it (x int) returns int begin return x * 2 end`,
+		`create view doubled as select id, double_it(amount) as doubled from orders`,
+		`create view big_doubled as select id from doubled where doubled > 100`,
+		`create trigger order_check before insert on orders
+		referencing new as new_order
+		for each row
+		begin
+			if new_order.amount < 0 then
+				raiserror 17000 'negative amount';
+			end if;
+		end`,
+		`grant select on orders to reader`,
+	)
+
+	opts := &DumpOptions{Users: true, Permissions: true}
+
+	var first bytes.Buffer
+	if err := Dump(context.Background(), db, &first, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	var second bytes.Buffer
+	if err := Dump(context.Background(), db, &second, opts); err != nil {
+		t.Fatal(err)
+	}
+	if first.String() != second.String() {
+		t.Fatalf("want deterministic dump, got:\n%s\nthen:\n%s", first.String(), second.String())
+	}
+
+	dump := first.String()
+	for _, want := range []string{
+		`CREATE USER "reader"`,
+		`CREATE SEQUENCE "DBA"."order_number" START WITH 1000 INCREMENT BY 10`,
+		`"name" varchar(40) NOT NULL`,
+		`CREATE INDEX "ix_amount" ON "DBA"."orders" ("amount" DESC)`,
+		`REFERENCES "DBA"."customer" ("id") ON DELETE CASCADE`,
+		`GRANT SELECT ON "DBA"."orders" TO "reader"`,
+		`CONSTRAINT "positive_quantity" CHECK (`,
+		`CREATE MATERIALIZED VIEW`,
+		`) ON COMMIT PRESERVE ROWS`,
+		`) ON COMMIT DELETE ROWS`,
+	} {
+		if !strings.Contains(dump, want) {
+			t.Fatalf("want %s in dump:\n%s", want, dump)
+		}
+	}
+
+	if strings.Index(dump, "double_it") > strings.Index(dump, "doubled") ||
+		strings.Index(dump, `"doubled"`) > strings.Index(dump, "big_doubled") {
+		t.Fatalf("want function, then view, then dependent view:\n%s", dump)
+	}
+
+	//the dump recreates the same schema in an empty database
+	target := testdb.New(t)
+	defer target.Cleanup()
+
+	targetDB, closeTarget := target.Open()
+	defer closeTarget()
+
+	for _, statement := range strings.Split(dump, "\ngo\n") {
+		if statement = strings.TrimSpace(statement); statement != "" {
+			target.Exec(targetDB, statement)
+		}
+	}
+
+	var replayed bytes.Buffer
+	if err := Dump(context.Background(), targetDB, &replayed, opts); err != nil {
+		t.Fatal(err)
+	}
+	if replayed.String() != dump {
+		t.Fatalf("want replayed dump identical, got:\n%s\nwant:\n%s", replayed.String(), dump)
+	}
+}
+
+func TestLoadChecks(t *testing.T) {
+	database := testdb.New(t)
+	defer database.Cleanup()
+
+	db, close := database.Open()
+	defer close()
+
+	database.Exec(db,
+		`create table product (
+			id int primary key,
+			price numeric(5, 2) null constraint nonnegative_price check (price >= 0),
+			constraint positive_id check (id > 0)
+		)`,
+	)
+
+	catalog, err := Load(context.Background(), db, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	product := catalog.Table("product")
+	if product == nil || len(product.Checks) != 2 {
+		t.Fatalf("want two checks, got %+v", product)
+	}
+	price, id := product.Checks[0], product.Checks[1]
+	if price.Name != "nonnegative_price" || !strings.EqualFold(price.Column, "price") || !strings.Contains(price.Condition, ">= 0") {
+		t.Fatalf("unexpected column check %+v", price)
+	}
+	if id.Name != "positive_id" || id.Column != "" || !strings.Contains(id.Condition, "> 0") {
+		t.Fatalf("unexpected table check %+v", id)
+	}
+}
+
+func TestCheckCondition(t *testing.T) {
+	for definition, want := range map[string]string{
+		"check (price >= 0)":       "price >= 0",
+		"CHECK(price >= 0)":        "price >= 0",
+		"(a > 0) or (b > 0)":       "(a > 0) or (b > 0)",
+		"check ((a > 0) or b > 0)": "(a > 0) or b > 0",
+		"check (name <> ')')":      "name <> ')'",
+	} {
+		if got := checkCondition(definition); got != want {
+			t.Errorf("%q: want %q, got %q", definition, want, got)
+		}
+	}
+}
+
+func TestOrderViews(t *testing.T) {
+	views := []*View{
+		{Owner: "o", Name: "a", DependsOn: []string{"o.c"}},
+		{Owner: "o", Name: "b"},
+		{Owner: "o", Name: "c", DependsOn: []string{"o.b", "other.x"}},
+	}
+
+	var names []string
+	for _, v := range orderViews(views) {
+		names = append(names, v.Name)
+	}
+	if strings.Join(names, " ") != "b c a" {
+		t.Fatalf("want dependencies first, got %v", names)
+	}
+}
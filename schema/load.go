@@ -0,0 +1,654 @@
+package schema
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+//Load reads the definitions of the objects selected by opts, which may be nil for the default options
+func Load(ctx context.Context, q Querier, opts *Options) (*Catalog, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	l := &loader{
+		q:          q,
+		ctx:        ctx,
+		catalog:    &Catalog{},
+		tables:     make(map[int]*Table),
+		views:      make(map[int]*View),
+		procedures: make(map[int]*Procedure),
+	}
+	l.filter, l.args = ownerFilter(opts)
+
+	for _, load := range []func() error{
+		l.loadUsers,
+		l.loadTables,
+		l.loadViews,
+		l.loadColumns,
+		l.loadIndexes,
+		l.loadForeignKeys,
+		l.loadChecks,
+		l.loadProcedures,
+		l.loadParameters,
+		l.loadTriggers,
+		l.loadSequences,
+		l.loadViewDependencies,
+		l.loadTableGrants,
+		l.loadProcedureGrants,
+	} {
+		if err := load(); err != nil {
+			return nil, err
+		}
+	}
+
+	return l.catalog, nil
+}
+
+//loader reads the catalog, keeping objects by id to attach their parts
+type loader struct {
+	q       Querier
+	ctx     context.Context
+	catalog *Catalog
+
+	//filter is a condition on the owner's name, u.user_name, with its args
+	filter string
+	args   []interface{}
+
+	tables     map[int]*Table
+	views      map[int]*View
+	procedures map[int]*Procedure
+}
+
+//ownerFilter returns the condition selecting the owners in opts, as u.user_name
+func ownerFilter(opts *Options) (string, []interface{}) {
+	owners, operator := opts.Owners, "IN"
+	if len(owners) == 0 {
+		owners, operator = SystemOwners, "NOT IN"
+	}
+
+	args := make([]interface{}, len(owners))
+	for i, owner := range owners {
+		args[i] = owner
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(owners)), ", ")
+	return fmt.Sprintf("u.user_name %s (%s)", operator, placeholders), args
+}
+
+//query runs the query, with the owner filter's args, calling scan for each row
+func (l *loader) query(query string, scan func(*sql.Rows) error) error {
+	rows, err := l.q.QueryContext(l.ctx, query, l.args...)
+	if err != nil {
+		return fmt.Errorf("did not query catalog: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return fmt.Errorf("did not read catalog: %v", err)
+		}
+	}
+	return rows.Err()
+}
+
+func (l *loader) loadUsers() error {
+	return l.query(`
+		SELECT u.user_id, u.user_name
+		FROM SYS.SYSUSER u
+		WHERE `+l.filter+`
+		ORDER BY u.user_name`,
+		func(rows *sql.Rows) error {
+			u := &User{}
+			if err := rows.Scan(&u.ID, &u.Name); err != nil {
+				return err
+			}
+			l.catalog.Users = append(l.catalog.Users, u)
+			return nil
+		})
+}
+
+func (l *loader) loadTables() error {
+	return l.query(`
+		SELECT t.table_id, u.user_name, t.table_name, t.table_type_str,
+			CASE WHEN t.table_type_str <> 'GBL TEMP' THEN ''
+				WHEN t.commit_action = 0 THEN 'ON COMMIT PRESERVE ROWS'
+				WHEN t.commit_action = 3 THEN 'NOT TRANSACTIONAL'
+				ELSE 'ON COMMIT DELETE ROWS' END
+		FROM SYS.SYSTAB t
+		JOIN SYS.SYSUSER u ON u.user_id = t.creator
+		WHERE t.table_type_str IN ('BASE', 'GBL TEMP') AND `+l.filter+`
+		ORDER BY u.user_name, t.table_name`,
+		func(rows *sql.Rows) error {
+			t := &Table{}
+			if err := rows.Scan(&t.ID, &t.Owner, &t.Name, &t.Type, &t.OnCommit); err != nil {
+				return err
+			}
+			l.catalog.Tables = append(l.catalog.Tables, t)
+			l.tables[t.ID] = t
+			return nil
+		})
+}
+
+func (l *loader) loadViews() error {
+	return l.query(`
+		SELECT t.table_id, u.user_name, t.table_name, t.table_type_str, v.view_def
+		FROM SYS.SYSVIEW v
+		JOIN SYS.SYSTAB t ON t.object_id = v.view_object_id
+		JOIN SYS.SYSUSER u ON u.user_id = t.creator
+		WHERE `+l.filter+`
+		ORDER BY u.user_name, t.table_name`,
+		func(rows *sql.Rows) error {
+			v := &View{}
+			var definition sql.NullString
+			if err := rows.Scan(&v.ID, &v.Owner, &v.Name, &v.Type, &definition); err != nil {
+				return err
+			}
+			v.Definition = definition.String
+			l.catalog.Views = append(l.catalog.Views, v)
+			l.views[v.ID] = v
+			return nil
+		})
+}
+
+func (l *loader) loadColumns() error {
+	return l.query(`
+		SELECT c.table_id, c.column_id, c.column_name, d.domain_name, c.base_type_str,
+			c.width, c.scale, c.nulls, c."default", c.column_type
+		FROM SYS.SYSTABCOL c
+		JOIN SYS.SYSDOMAIN d ON d.domain_id = c.domain_id
+		JOIN SYS.SYSTAB t ON t.table_id = c.table_id
+		JOIN SYS.SYSUSER u ON u.user_id = t.creator
+		WHERE `+l.filter+`
+		ORDER BY c.table_id, c.column_id`,
+		func(rows *sql.Rows) error {
+			var (
+				tableID           int
+				nulls, columnType string
+				typ, defaultValue sql.NullString
+			)
+			c := &Column{}
+			if err := rows.Scan(&tableID, &c.ID, &c.Name, &c.Domain, &typ, &c.Width, &c.Scale, &nulls, &defaultValue, &columnType); err != nil {
+				return err
+			}
+			c.Type = typ.String
+			if c.Type == "" {
+				c.Type = c.Domain
+			}
+			c.Nullable = nulls == "Y"
+			c.Default = defaultValue.String
+			c.Computed = columnType == "C"
+
+			if t, ok := l.tables[tableID]; ok {
+				t.Columns = append(t.Columns, c)
+			} else if v, ok := l.views[tableID]; ok {
+				v.Columns = append(v.Columns, c)
+			}
+			return nil
+		})
+}
+
+//index categories in SYS.SYSIDX
+const (
+	indexPrimaryKey = 1
+	indexForeignKey = 2
+	indexSecondary  = 3
+)
+
+//index uniqueness in SYS.SYSIDX
+const (
+	indexUniqueConstraint = 2
+	indexNonUnique        = 4
+)
+
+func (l *loader) loadIndexes() error {
+	indexes := make(map[[2]int]*Index)
+
+	err := l.query(`
+		SELECT i.table_id, i.index_id, i.index_name, i.index_category, i."unique"
+		FROM SYS.SYSIDX i
+		JOIN SYS.SYSTAB t ON t.table_id = i.table_id
+		JOIN SYS.SYSUSER u ON u.user_id = t.creator
+		WHERE i.index_category IN (1, 3) AND `+l.filter+`
+		ORDER BY i.table_id, i.index_name`,
+		func(rows *sql.Rows) error {
+			var tableID, category, unique int
+			i := &Index{}
+			if err := rows.Scan(&tableID, &i.ID, &i.Name, &category, &unique); err != nil {
+				return err
+			}
+			i.Unique = unique != indexNonUnique
+			i.Constraint = unique == indexUniqueConstraint
+
+			t, ok := l.tables[tableID]
+			if !ok {
+				return nil
+			}
+			if category == indexPrimaryKey {
+				t.PrimaryKey = i
+			} else {
+				t.Indexes = append(t.Indexes, i)
+			}
+			indexes[[2]int{tableID, i.ID}] = i
+			return nil
+		})
+	if err != nil {
+		return err
+	}
+
+	return l.query(`
+		SELECT ic.table_id, ic.index_id, c.column_name, ic."order"
+		FROM SYS.SYSIDXCOL ic
+		JOIN SYS.SYSTABCOL c ON c.table_id = ic.table_id AND c.column_id = ic.column_id
+		JOIN SYS.SYSTAB t ON t.table_id = ic.table_id
+		JOIN SYS.SYSUSER u ON u.user_id = t.creator
+		WHERE `+l.filter+`
+		ORDER BY ic.table_id, ic.index_id, ic.sequence`,
+		func(rows *sql.Rows) error {
+			var tableID, indexID int
+			var order string
+			c := &IndexColumn{}
+			if err := rows.Scan(&tableID, &indexID, &c.Name, &order); err != nil {
+				return err
+			}
+			c.Descending = order == "D"
+
+			if i, ok := indexes[[2]int{tableID, indexID}]; ok {
+				i.Columns = append(i.Columns, c)
+			}
+			return nil
+		})
+}
+
+//loadChecks reads the table and column CHECK constraints, of constraint type T and C
+func (l *loader) loadChecks() error {
+	return l.query(`
+		SELECT t.table_id, c.constraint_name, col.column_name, ch.check_defn
+		FROM SYS.SYSCONSTRAINT c
+		JOIN SYS.SYSCHECK ch ON ch.check_id = c.constraint_id
+		JOIN SYS.SYSTAB t ON t.object_id = c.table_object_id
+		JOIN SYS.SYSUSER u ON u.user_id = t.creator
+		LEFT OUTER JOIN SYS.SYSTABCOL col ON c.constraint_type = 'C' AND col.object_id = c.ref_object_id
+		WHERE c.constraint_type IN ('T', 'C') AND `+l.filter+`
+		ORDER BY t.table_id, c.constraint_name`,
+		func(rows *sql.Rows) error {
+			var tableID int
+			var column sql.NullString
+			var definition string
+			c := &Check{}
+			if err := rows.Scan(&tableID, &c.Name, &column, &definition); err != nil {
+				return err
+			}
+			c.Column = column.String
+			c.Condition = checkCondition(definition)
+
+			if t, ok := l.tables[tableID]; ok {
+				t.Checks = append(t.Checks, c)
+			}
+			return nil
+		})
+}
+
+//checkCondition returns the search condition of a check definition stored as CHECK (condition)
+func checkCondition(definition string) string {
+	condition := strings.TrimSpace(definition)
+	if len(condition) >= 5 && strings.EqualFold(condition[:5], "check") {
+		condition = strings.TrimSpace(condition[5:])
+	}
+
+	//remove the parentheses enclosing the whole condition, but not those of (a) or (b)
+	if strings.HasPrefix(condition, "(") && strings.HasSuffix(condition, ")") && closingParen(condition) == len(condition)-1 {
+		condition = strings.TrimSpace(condition[1 : len(condition)-1])
+	}
+	return condition
+}
+
+//closingParen returns the index of the parenthesis closing the one opening s, ignoring those in quotes, or -1
+func closingParen(s string) int {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+//referentialActions maps SYS.SYSTRIGGER.referential_action to the action's SQL
+var referentialActions = map[string]string{
+	"C": "CASCADE",
+	"N": "SET NULL",
+	"D": "SET DEFAULT",
+	"R": "RESTRICT",
+}
+
+func (l *loader) loadForeignKeys() error {
+	keys := make(map[[2]int]*ForeignKey)
+
+	err := l.query(`
+		SELECT f.foreign_table_id, f.foreign_index_id, i.index_name, pu.user_name, pt.table_name, f.check_on_commit
+		FROM SYS.SYSFKEY f
+		JOIN SYS.SYSIDX i ON i.table_id = f.foreign_table_id AND i.index_id = f.foreign_index_id
+		JOIN SYS.SYSTAB pt ON pt.table_id = f.primary_table_id
+		JOIN SYS.SYSUSER pu ON pu.user_id = pt.creator
+		JOIN SYS.SYSTAB t ON t.table_id = f.foreign_table_id
+		JOIN SYS.SYSUSER u ON u.user_id = t.creator
+		WHERE `+l.filter+`
+		ORDER BY f.foreign_table_id, i.index_name`,
+		func(rows *sql.Rows) error {
+			var tableID int
+			var checkOnCommit string
+			k := &ForeignKey{}
+			if err := rows.Scan(&tableID, &k.indexID, &k.Name, &k.ReferencedOwner, &k.ReferencedTable, &checkOnCommit); err != nil {
+				return err
+			}
+			k.CheckOnCommit = checkOnCommit == "Y"
+
+			if t, ok := l.tables[tableID]; ok {
+				t.ForeignKeys = append(t.ForeignKeys, k)
+				keys[[2]int{tableID, k.indexID}] = k
+			}
+			return nil
+		})
+	if err != nil {
+		return err
+	}
+
+	err = l.query(`
+		SELECT f.foreign_table_id, f.foreign_index_id, fc.column_name, pc.column_name
+		FROM SYS.SYSFKEY f
+		JOIN SYS.SYSIDXCOL ic ON ic.table_id = f.foreign_table_id AND ic.index_id = f.foreign_index_id
+		JOIN SYS.SYSTABCOL fc ON fc.table_id = ic.table_id AND fc.column_id = ic.column_id
+		JOIN SYS.SYSTABCOL pc ON pc.table_id = f.primary_table_id AND pc.column_id = ic.primary_column_id
+		JOIN SYS.SYSTAB t ON t.table_id = f.foreign_table_id
+		JOIN SYS.SYSUSER u ON u.user_id = t.creator
+		WHERE `+l.filter+`
+		ORDER BY f.foreign_table_id, f.foreign_index_id, ic.sequence`,
+		func(rows *sql.Rows) error {
+			var tableID, indexID int
+			var column, referenced string
+			if err := rows.Scan(&tableID, &indexID, &column, &referenced); err != nil {
+				return err
+			}
+			if k, ok := keys[[2]int{tableID, indexID}]; ok {
+				k.Columns = append(k.Columns, column)
+				k.ReferencedColumns = append(k.ReferencedColumns, referenced)
+			}
+			return nil
+		})
+	if err != nil {
+		return err
+	}
+
+	//referential actions are implemented as system triggers on the primary table
+	return l.query(`
+		SELECT tr.foreign_table_id, tr.foreign_key_id, tr.event, tr.referential_action
+		FROM SYS.SYSTRIGGER tr
+		JOIN SYS.SYSTAB t ON t.table_id = tr.foreign_table_id
+		JOIN SYS.SYSUSER u ON u.user_id = t.creator
+		WHERE tr.referential_action IS NOT NULL AND `+l.filter,
+		func(rows *sql.Rows) error {
+			var tableID, indexID int
+			var event, action string
+			if err := rows.Scan(&tableID, &indexID, &event, &action); err != nil {
+				return err
+			}
+
+			k, ok := keys[[2]int{tableID, indexID}]
+			if !ok {
+				return nil
+			}
+			if event == "D" {
+				k.OnDelete = referentialActions[action]
+			} else {
+				k.OnUpdate = referentialActions[action]
+			}
+			return nil
+		})
+}
+
+func (l *loader) loadProcedures() error {
+	return l.query(`
+		SELECT p.proc_id, u.user_name, p.proc_name, p.proc_defn
+		FROM SYS.SYSPROCEDURE p
+		JOIN SYS.SYSUSER u ON u.user_id = p.creator
+		WHERE `+l.filter+`
+		ORDER BY u.user_name, p.proc_name`,
+		func(rows *sql.Rows) error {
+			p := &Procedure{}
+			var definition sql.NullString
+			if err := rows.Scan(&p.ID, &p.Owner, &p.Name, &definition); err != nil {
+				return err
+			}
+			p.Definition = definition.String
+			l.catalog.Procedures = append(l.catalog.Procedures, p)
+			l.procedures[p.ID] = p
+			return nil
+		})
+}
+
+//parameter types in SYS.SYSPROCPARM
+const (
+	parmVariable    = 0
+	parmResult      = 1
+	parmReturnValue = 4
+)
+
+func (l *loader) loadParameters() error {
+	return l.query(`
+		SELECT pp.proc_id, pp.parm_id, pp.parm_name, pp.parm_type, pp.parm_mode_in, pp.parm_mode_out,
+			d.domain_name, pp.base_type_str, pp.width, pp.scale, pp."default"
+		FROM SYS.SYSPROCPARM pp
+		JOIN SYS.SYSDOMAIN d ON d.domain_id = pp.domain_id
+		JOIN SYS.SYSPROCEDURE p ON p.proc_id = pp.proc_id
+		JOIN SYS.SYSUSER u ON u.user_id = p.creator
+		WHERE `+l.filter+`
+		ORDER BY pp.proc_id, pp.parm_id`,
+		func(rows *sql.Rows) error {
+			var (
+				procID, parmType  int
+				in, out           string
+				typ, defaultValue sql.NullString
+			)
+			p := &Parameter{}
+			err := rows.Scan(&procID, &p.ID, &p.Name, &parmType, &in, &out, &p.Domain, &typ, &p.Width, &p.Scale, &defaultValue)
+			if err != nil {
+				return err
+			}
+			p.Type = typ.String
+			if p.Type == "" {
+				p.Type = p.Domain
+			}
+			p.Default = defaultValue.String
+
+			switch {
+			case in == "Y" && out == "Y":
+				p.Mode = "INOUT"
+			case out == "Y":
+				p.Mode = "OUT"
+			default:
+				p.Mode = "IN"
+			}
+
+			proc, ok := l.procedures[procID]
+			if !ok {
+				return nil
+			}
+			switch parmType {
+			case parmVariable:
+				proc.Parameters = append(proc.Parameters, p)
+			case parmResult:
+				proc.Results = append(proc.Results, p)
+			case parmReturnValue:
+				proc.Returns = p
+			}
+			return nil
+		})
+}
+
+func (l *loader) loadTriggers() error {
+	return l.query(`
+		SELECT tr.table_id, tr.trigger_id, tr.trigger_name, tr.trigger_defn
+		FROM SYS.SYSTRIGGER tr
+		JOIN SYS.SYSTAB t ON t.table_id = tr.table_id
+		JOIN SYS.SYSUSER u ON u.user_id = t.creator
+		WHERE tr.referential_action IS NULL AND `+l.filter+`
+		ORDER BY tr.table_id, tr.trigger_name`,
+		func(rows *sql.Rows) error {
+			var tableID int
+			var name, definition sql.NullString
+			tr := &Trigger{}
+			if err := rows.Scan(&tableID, &tr.ID, &name, &definition); err != nil {
+				return err
+			}
+			tr.Name = name.String
+			tr.Definition = definition.String
+
+			if t, ok := l.tables[tableID]; ok {
+				t.Triggers = append(t.Triggers, tr)
+			}
+			return nil
+		})
+}
+
+func (l *loader) loadSequences() error {
+	return l.query(`
+		SELECT u.user_name, s.sequence_name, s.start_with, s.increment_by, s.min_value, s.max_value, s.cycle, s.cache
+		FROM SYS.SYSSEQUENCE s
+		JOIN SYS.SYSUSER u ON u.user_id = s.owner
+		WHERE `+l.filter+`
+		ORDER BY u.user_name, s.sequence_name`,
+		func(rows *sql.Rows) error {
+			s := &Sequence{}
+			err := rows.Scan(&s.Owner, &s.Name, &s.StartWith, &s.Increment, &s.MinValue, &s.MaxValue, &s.Cycle, &s.Cache)
+			if err != nil {
+				return err
+			}
+			l.catalog.Sequences = append(l.catalog.Sequences, s)
+			return nil
+		})
+}
+
+//loadViewDependencies finds the views each view selects from
+func (l *loader) loadViewDependencies() error {
+	return l.query(`
+		SELECT dt.table_id, ru.user_name, rt.table_name
+		FROM SYS.SYSDEPENDENCY d
+		JOIN SYS.SYSTAB dt ON dt.object_id = d.dep_object_id
+		JOIN SYS.SYSTAB rt ON rt.object_id = d.ref_object_id
+		JOIN SYS.SYSUSER ru ON ru.user_id = rt.creator
+		JOIN SYS.SYSUSER u ON u.user_id = dt.creator
+		WHERE rt.table_type_str IN ('VIEW', 'MAT VIEW') AND `+l.filter+`
+		ORDER BY dt.table_id, ru.user_name, rt.table_name`,
+		func(rows *sql.Rows) error {
+			var viewID int
+			var owner, name string
+			if err := rows.Scan(&viewID, &owner, &name); err != nil {
+				return err
+			}
+			if v, ok := l.views[viewID]; ok {
+				v.DependsOn = append(v.DependsOn, owner+"."+name)
+			}
+			return nil
+		})
+}
+
+//tablePrivileges are the columns of SYS.SYSTABLEPERM, with the privilege each grants
+var tablePrivileges = []struct {
+	column, privilege string
+}{
+	{"selectauth", "SELECT"},
+	{"insertauth", "INSERT"},
+	{"updateauth", "UPDATE"},
+	{"deleteauth", "DELETE"},
+	{"alterauth", "ALTER"},
+	{"referenceauth", "REFERENCES"},
+}
+
+//loadTableGrants reads the privileges granted on tables and views
+func (l *loader) loadTableGrants() error {
+	columns := make([]string, len(tablePrivileges))
+	for i, p := range tablePrivileges {
+		columns[i] = "p." + p.column
+	}
+
+	return l.query(`
+		SELECT p.stable_id, g.user_name, `+strings.Join(columns, ", ")+`
+		FROM SYS.SYSTABLEPERM p
+		JOIN SYS.SYSUSER g ON g.user_id = p.grantee
+		JOIN SYS.SYSTAB t ON t.table_id = p.stable_id
+		JOIN SYS.SYSUSER u ON u.user_id = t.creator
+		WHERE `+l.filter+`
+		ORDER BY p.stable_id, g.user_name`,
+		func(rows *sql.Rows) error {
+			var tableID int
+			g := &Grant{}
+			auth := make([]string, len(tablePrivileges))
+			dest := []interface{}{&tableID, &g.Grantee}
+			for i := range auth {
+				dest = append(dest, &auth[i])
+			}
+			if err := rows.Scan(dest...); err != nil {
+				return err
+			}
+
+			//Y is granted, G is granted with grant option
+			for i, p := range tablePrivileges {
+				switch auth[i] {
+				case "Y":
+					g.Privileges = append(g.Privileges, p.privilege)
+				case "G":
+					g.Privileges = append(g.Privileges, p.privilege)
+					g.WithGrantOption = append(g.WithGrantOption, p.privilege)
+				}
+			}
+			if len(g.Privileges) == 0 {
+				return nil
+			}
+
+			if t, ok := l.tables[tableID]; ok {
+				t.Grants = append(t.Grants, g)
+			} else if v, ok := l.views[tableID]; ok {
+				v.Grants = append(v.Grants, g)
+			}
+			return nil
+		})
+}
+
+func (l *loader) loadProcedureGrants() error {
+	return l.query(`
+		SELECT pp.proc_id, g.user_name
+		FROM SYS.SYSPROCPERM pp
+		JOIN SYS.SYSUSER g ON g.user_id = pp.grantee
+		JOIN SYS.SYSPROCEDURE p ON p.proc_id = pp.proc_id
+		JOIN SYS.SYSUSER u ON u.user_id = p.creator
+		WHERE `+l.filter+`
+		ORDER BY pp.proc_id, g.user_name`,
+		func(rows *sql.Rows) error {
+			var procID int
+			g := &Grant{Privileges: []string{"EXECUTE"}}
+			if err := rows.Scan(&procID, &g.Grantee); err != nil {
+				return err
+			}
+			if p, ok := l.procedures[procID]; ok {
+				p.Grants = append(p.Grants, g)
+			}
+			return nil
+		})
+}
@@ -0,0 +1,293 @@
+//Package schema reads the definitions of database objects from the SQL Anywhere system catalog.
+//
+//It uses only database/sql, so works with any *sql.DB, *sql.Conn or *sql.Tx of the sqlanywhere driver.
+package schema
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+)
+
+//Querier runs queries, implemented by *sql.DB, *sql.Conn and *sql.Tx
+type Querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+//SystemOwners own the system objects, excluded from a Catalog unless Options.Owners names them
+var SystemOwners = []string{
+	"SYS",
+	"dbo",
+	"rs_systabgroup",
+	"SA_DEBUG",
+	"diagnostics",
+	"ml_server",
+	"SYS_SPATIAL_ADMIN_ROLE",
+}
+
+//Options select the objects read into a Catalog
+type Options struct {
+	//Owners are the users whose objects are read. If empty, objects of all but the SystemOwners are read.
+	Owners []string
+}
+
+//Catalog holds the definitions of the objects in a database, each list ordered by owner and name
+type Catalog struct {
+	Users      []*User
+	Tables     []*Table
+	Views      []*View
+	Procedures []*Procedure
+	Sequences  []*Sequence
+}
+
+//User is a database user, from SYS.SYSUSER
+type User struct {
+	ID   int
+	Name string
+}
+
+//Table is a base or global temporary table, from SYS.SYSTAB
+type Table struct {
+	ID    int
+	Owner string
+	Name  string
+
+	//Type is BASE or GBL TEMP
+	Type string
+
+	//OnCommit is what a global temporary table does with its rows at commit: ON COMMIT DELETE ROWS,
+	//ON COMMIT PRESERVE ROWS or NOT TRANSACTIONAL. It is empty for a base table.
+	OnCommit string
+
+	Columns     []*Column
+	PrimaryKey  *Index
+	Indexes     []*Index
+	ForeignKeys []*ForeignKey
+	Checks      []*Check
+	Triggers    []*Trigger
+	Grants      []*Grant
+}
+
+//Column is a column of a table or view, from SYS.SYSTABCOL
+type Column struct {
+	ID   int
+	Name string
+
+	//Domain is the name of the data type, such as varchar, and Type the full type, such as varchar(20)
+	Domain string
+	Type   string
+
+	Width    int
+	Scale    int
+	Nullable bool
+
+	//Default is the default value expression, empty if none
+	Default string
+
+	//Computed is true for a computed column, whose Default is the expression computing it
+	Computed bool
+}
+
+//Index is an index, primary key or unique constraint, from SYS.SYSIDX and SYS.SYSIDXCOL
+type Index struct {
+	ID   int
+	Name string
+
+	//Unique is true for unique indexes, unique constraints and primary keys.
+	//Constraint is true for a unique constraint, created with the table rather than by CREATE INDEX.
+	Unique     bool
+	Constraint bool
+
+	Columns []*IndexColumn
+}
+
+//IndexColumn is a column of an index
+type IndexColumn struct {
+	Name       string
+	Descending bool
+}
+
+//ForeignKey is a foreign key of a table, from SYS.SYSFKEY
+type ForeignKey struct {
+	Name    string
+	Columns []string
+
+	//ReferencedOwner and ReferencedTable name the primary table, and ReferencedColumns its columns
+	ReferencedOwner   string
+	ReferencedTable   string
+	ReferencedColumns []string
+
+	//OnUpdate and OnDelete are the referential actions, such as CASCADE, or empty for the default, RESTRICT
+	OnUpdate string
+	OnDelete string
+
+	//CheckOnCommit is true if the key is checked when committing rather than on each statement
+	CheckOnCommit bool
+
+	//indexID is the id of the foreign key's index on the table
+	indexID int
+}
+
+//Check is a CHECK constraint on a table or one of its columns, from SYS.SYSCONSTRAINT and SYS.SYSCHECK
+type Check struct {
+	Name string
+
+	//Column is the column of a column check, empty for a table check
+	Column string
+
+	//Condition is the search condition, without the CHECK keyword and its parentheses
+	Condition string
+}
+
+//View is a view or materialized view, from SYS.SYSVIEW
+type View struct {
+	ID    int
+	Owner string
+	Name  string
+
+	//Type is VIEW or MAT VIEW
+	Type string
+
+	Definition string
+	Columns    []*Column
+	Grants     []*Grant
+
+	//DependsOn are the views this view selects from, qualified by owner
+	DependsOn []string
+}
+
+//Procedure is a procedure or function, from SYS.SYSPROCEDURE and SYS.SYSPROCPARM
+type Procedure struct {
+	ID    int
+	Owner string
+	Name  string
+
+	//Definition is the CREATE statement of the procedure, empty if hidden
+	Definition string
+
+	Parameters []*Parameter
+
+	//Results are the columns of the result set, if any
+	Results []*Parameter
+
+	//Returns is the return value of a function, nil for a procedure
+	Returns *Parameter
+
+	Grants []*Grant
+}
+
+//IsFunction reports whether the procedure is a function, returning a value
+func (p *Procedure) IsFunction() bool {
+	return p.Returns != nil
+}
+
+//Parameter is a parameter, result column or return value of a procedure
+type Parameter struct {
+	ID   int
+	Name string
+
+	//Mode is IN, OUT or INOUT
+	Mode string
+
+	Domain  string
+	Type    string
+	Width   int
+	Scale   int
+	Default string
+}
+
+//Trigger is a trigger on a table, from SYS.SYSTRIGGER.
+//The system triggers implementing referential actions are not included.
+type Trigger struct {
+	ID   int
+	Name string
+
+	//Definition is the CREATE TRIGGER statement
+	Definition string
+}
+
+//Sequence is a sequence generator, from SYS.SYSSEQUENCE
+type Sequence struct {
+	Owner     string
+	Name      string
+	StartWith int64
+	Increment int64
+	MinValue  int64
+	MaxValue  int64
+	Cycle     bool
+
+	//Cache is the number of values cached, zero if none
+	Cache int64
+}
+
+//Grant is the privileges on an object granted to a user, from SYS.SYSTABLEPERM or SYS.SYSPROCPERM
+type Grant struct {
+	Grantee string
+
+	//Privileges are such as SELECT, INSERT, UPDATE, DELETE, ALTER, REFERENCES or EXECUTE
+	Privileges []string
+
+	//WithGrantOption are the privileges the grantee may grant to others
+	WithGrantOption []string
+}
+
+//Table returns the table with name, which may be qualified by owner, or nil if there is none.
+//Names are matched ignoring case, as by the server.
+func (c *Catalog) Table(name string) *Table {
+	owner, name := splitName(name)
+	for _, t := range c.Tables {
+		if matches(owner, name, t.Owner, t.Name) {
+			return t
+		}
+	}
+	return nil
+}
+
+//View returns the view with name, which may be qualified by owner, or nil if there is none
+func (c *Catalog) View(name string) *View {
+	owner, name := splitName(name)
+	for _, v := range c.Views {
+		if matches(owner, name, v.Owner, v.Name) {
+			return v
+		}
+	}
+	return nil
+}
+
+//Procedure returns the procedure or function with name, which may be qualified by owner, or nil if there is none
+func (c *Catalog) Procedure(name string) *Procedure {
+	owner, name := splitName(name)
+	for _, p := range c.Procedures {
+		if matches(owner, name, p.Owner, p.Name) {
+			return p
+		}
+	}
+	return nil
+}
+
+//QualifiedName returns the view's name qualified by its owner
+func (v *View) QualifiedName() string {
+	return v.Owner + "." + v.Name
+}
+
+//Column returns the column with name, or nil if there is none
+func (t *Table) Column(name string) *Column {
+	for _, c := range t.Columns {
+		if strings.EqualFold(c.Name, name) {
+			return c
+		}
+	}
+	return nil
+}
+
+//splitName splits owner.name, returning an empty owner if unqualified
+func splitName(name string) (string, string) {
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return "", name
+}
+
+func matches(owner, name, objectOwner, objectName string) bool {
+	return (owner == "" || strings.EqualFold(owner, objectOwner)) && strings.EqualFold(name, objectName)
+}
@@ -0,0 +1,113 @@
+package schema
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/mdcnz/sqlanywhere/internal/testdb"
+)
+
+func TestLoad(t *testing.T) {
+	database := testdb.New(t)
+	defer database.Cleanup()
+
+	db, close := database.Open()
+	defer close()
+
+	database.Exec(db,
+		`create table customer (
+			id int primary key,
+			name varchar(40) not null,
+			email varchar(80) null default 'none',
+			unique (email)
+		)`,
+		`create table orders (
+			id int primary key default autoincrement,
+			customer_id int not null,
+			amount numeric(10, 2),
+			constraint fk_customer foreign key (customer_id) references customer (id) on delete cascade
+		)`,
+		`create index ix_amount on orders (amount desc)`,
+		`create view big_orders as select id, amount from orders where amount > 100`,
+		`create procedure list_orders (in customer int, out total numeric(10, 2))
+		result (id int, amount numeric(10, 2))
+		begin
+			select sum(amount) into total from orders where customer_id = customer;
+			select id, amount from orders where customer_id = customer;
+		end`,
+		`create function double_it (x int) returns int begin return x * 2 end`,
+	)
+
+	catalog, err := Load(context.Background(), db, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if catalog.Table("sys.systab") != nil {
+		t.Fatal("want system tables excluded")
+	}
+
+	customer := catalog.Table("dba.customer")
+	if customer == nil {
+		t.Fatalf("want customer table, got %+v", catalog.Tables)
+	}
+	if len(customer.Columns) != 3 || customer.PrimaryKey == nil || customer.PrimaryKey.Columns[0].Name != "id" {
+		t.Fatalf("unexpected customer table %+v", customer)
+	}
+
+	name := customer.Column("name")
+	if name.Domain != "varchar" || name.Width != 40 || name.Nullable {
+		t.Fatalf("unexpected name column %+v", name)
+	}
+	if email := customer.Column("email"); !email.Nullable || email.Default != "'none'" {
+		t.Fatalf("unexpected email column %+v", email)
+	}
+	if len(customer.Indexes) != 1 || !customer.Indexes[0].Unique || !customer.Indexes[0].Constraint {
+		t.Fatalf("want unique constraint on email, got %+v", customer.Indexes)
+	}
+
+	orders := catalog.Table("orders")
+	if amount := orders.Column("amount"); amount.Width != 10 || amount.Scale != 2 {
+		t.Fatalf("unexpected amount column %+v", amount)
+	}
+	if len(orders.Indexes) != 1 || orders.Indexes[0].Name != "ix_amount" || !orders.Indexes[0].Columns[0].Descending {
+		t.Fatalf("want descending index on amount, got %+v", orders.Indexes)
+	}
+	if len(orders.ForeignKeys) != 1 {
+		t.Fatalf("want one foreign key, got %+v", orders.ForeignKeys)
+	}
+	fk := orders.ForeignKeys[0]
+	if fk.Name != "fk_customer" || fk.ReferencedTable != "customer" ||
+		!reflect.DeepEqual(fk.Columns, []string{"customer_id"}) ||
+		!reflect.DeepEqual(fk.ReferencedColumns, []string{"id"}) ||
+		fk.OnDelete != "CASCADE" {
+		t.Fatalf("unexpected foreign key %+v", fk)
+	}
+
+	view := catalog.View("big_orders")
+	if view == nil || len(view.Columns) != 2 || view.Definition == "" {
+		t.Fatalf("unexpected view %+v", view)
+	}
+
+	proc := catalog.Procedure("list_orders")
+	if proc == nil || proc.IsFunction() || len(proc.Parameters) != 2 || len(proc.Results) != 2 {
+		t.Fatalf("unexpected procedure %+v", proc)
+	}
+	if proc.Parameters[0].Mode != "IN" || proc.Parameters[1].Mode != "OUT" {
+		t.Fatalf("unexpected parameter modes %+v %+v", proc.Parameters[0], proc.Parameters[1])
+	}
+
+	function := catalog.Procedure("double_it")
+	if function == nil || !function.IsFunction() || function.Returns.Domain != "integer" {
+		t.Fatalf("unexpected function %+v", function)
+	}
+
+	only, err := Load(context.Background(), db, &Options{Owners: []string{"SYS"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if only.Table("customer") != nil || only.View("systab") == nil {
+		t.Fatal("want only the SYS objects")
+	}
+}
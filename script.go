@@ -0,0 +1,92 @@
+package sqlanywhere
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/mdcnz/sqlanywhere/internal/sqlsplit"
+)
+
+//Execer executes statements, implemented by *sql.DB, *sql.Conn and *sql.Tx
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+//ScriptOptions configure ExecScript
+type ScriptOptions struct {
+	//Delimiter ends statements, as the dbisql command_delimiter option. Empty is a semicolon,
+	//with semicolons within BEGIN ... END blocks not ending statements. A line containing
+	//only "go" always ends a statement.
+	Delimiter string
+
+	//ContinueOnError executes the statements after one that fails, returning ScriptErrors
+	ContinueOnError bool
+}
+
+//ScriptError is the error of a statement of a script
+type ScriptError struct {
+	//Line is the line of the script the statement starts on, counting from 1
+	Line      int
+	Statement string
+	Err       error
+}
+
+func (e *ScriptError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *ScriptError) Unwrap() error {
+	return e.Err
+}
+
+//ScriptErrors are the errors of the statements of a script executed with ContinueOnError
+type ScriptErrors []*ScriptError
+
+func (e ScriptErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return fmt.Sprintf("%d statements failed: %s", len(e), strings.Join(messages, "; "))
+}
+
+//ExecScript executes the statements of a script written for dbisql, in order. It stops at the first
+//statement that fails, returning a *ScriptError, unless opts.ContinueOnError is set. opts may be nil.
+//
+//Use a *sql.Conn or *sql.Tx for scripts relying on connection state, such as temporary options,
+//since statements executed with a *sql.DB may each use a different connection.
+func ExecScript(ctx context.Context, conn Execer, r io.Reader, opts *ScriptOptions) error {
+	if opts == nil {
+		opts = &ScriptOptions{}
+	}
+
+	script, err := ioutil.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("did not read script: %v", err)
+	}
+
+	statements, err := sqlsplit.SplitDelimited(string(script), opts.Delimiter)
+	if err != nil {
+		return fmt.Errorf("did not split script: %v", err)
+	}
+
+	var errs ScriptErrors
+	for _, statement := range statements {
+		if _, err := conn.ExecContext(ctx, statement.SQL); err != nil {
+			scriptErr := &ScriptError{Line: statement.Line, Statement: statement.SQL, Err: err}
+			if !opts.ContinueOnError || ctx.Err() != nil {
+				return scriptErr
+			}
+			errs = append(errs, scriptErr)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package sqlanywhere
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const testScript = `-- fixtures, as for dbisql
+create table script_test (id int primary key, name varchar(20));
+
+create procedure add_script_row(in new_id int)
+begin
+	declare new_name varchar(20);
+	set new_name = 'row ' || new_id;
+	insert into script_test values (new_id, new_name);
+end;
+
+call add_script_row(1)
+go
+
+/* a duplicate; fails */
+call add_script_row(1);
+call add_script_row(2);
+`
+
+func TestExecScript(t *testing.T) {
+	testdb := NewTestDB(t)
+	defer testdb.Cleanup()
+
+	db, close := testdb.Open()
+	defer close()
+
+	ctx := context.Background()
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	err = ExecScript(ctx, conn, strings.NewReader(testScript), nil)
+	var scriptErr *ScriptError
+	if !errors.As(err, &scriptErr) || scriptErr.Line != 15 || scriptErr.Statement != "call add_script_row(1)" {
+		t.Fatalf("want duplicate at line 15, got %v", err)
+	}
+	if count := countRows(t, db, "script_test"); count != 1 {
+		t.Fatalf("want script stopped at error, got %d rows", count)
+	}
+
+	if _, err := conn.ExecContext(ctx, "drop procedure add_script_row"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.ExecContext(ctx, "drop table script_test"); err != nil {
+		t.Fatal(err)
+	}
+
+	err = ExecScript(ctx, conn, strings.NewReader(testScript), &ScriptOptions{ContinueOnError: true})
+	var scriptErrs ScriptErrors
+	if !errors.As(err, &scriptErrs) || len(scriptErrs) != 1 || scriptErrs[0].Line != 15 {
+		t.Fatalf("want one error at line 15, got %v", err)
+	}
+	if count := countRows(t, db, "script_test"); count != 2 {
+		t.Fatalf("want script continued after error, got %d rows", count)
+	}
+
+	delimited := "insert into script_test values (3, 'a;b') $$ insert into script_test values (4, 'c') $$"
+	if err := ExecScript(ctx, conn, strings.NewReader(delimited), &ScriptOptions{Delimiter: "$$"}); err != nil {
+		t.Fatal(err)
+	}
+	if count := countRows(t, db, "script_test"); count != 4 {
+		t.Fatalf("want rows inserted by delimited script, got %d rows", count)
+	}
+}
@@ -0,0 +1,111 @@
+package sqlanywhere
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"time"
+)
+
+//SlowQuery describes a statement that took at least the connector's slow query threshold to execute
+type SlowQuery struct {
+	SQL string
+
+	//Args describes each argument by name or position and type, without its value
+	Args []string
+
+	Duration time.Duration
+
+	//Err is the error returned by the statement, if any
+	Err error
+
+	//Plan is the server's plan for the statement, if requested with WithSlowQueryPlan.
+	//PlanErr is the error getting it, if any, such as context.DeadlineExceeded if planning timed out.
+	Plan    string
+	PlanErr error
+}
+
+//defaultSlowQueryPlanTimeout bounds planning a slow query, unless WithSlowQueryPlan gives a timeout
+const defaultSlowQueryPlanTimeout = time.Second
+
+//slowQueryCheck times a statement executed on the connection, nil if slow queries are not reported
+type slowQueryCheck struct {
+	con   *connection
+	ctx   context.Context
+	start time.Time
+}
+
+func (con *connection) startSlowQueryCheck(ctx context.Context) *slowQueryCheck {
+	if con.connector == nil || con.connector.slowQueryThreshold <= 0 || con.connector.slowQueryReport == nil {
+		return nil
+	}
+	return &slowQueryCheck{con: con, ctx: ctx, start: time.Now()}
+}
+
+//end reports the statement if it took at least the threshold
+func (c *slowQueryCheck) end(query string, args []driver.NamedValue, err error) {
+	if c == nil {
+		return
+	}
+
+	elapsed := time.Since(c.start)
+	connector := c.con.connector
+	if elapsed < connector.slowQueryThreshold {
+		return
+	}
+
+	slow := SlowQuery{
+		SQL:      query,
+		Args:     redact(args),
+		Duration: elapsed,
+		Err:      err,
+	}
+
+	if connector.slowQueryPlan && c.ctx.Err() == nil && c.con.isValid() {
+		slow.Plan, slow.PlanErr = c.planSlowQuery(query)
+	}
+
+	connector.slowQueryReport(slow)
+}
+
+//planSlowQuery returns the plan of a slow query, taking at most the connector's plan timeout
+func (c *slowQueryCheck) planSlowQuery(query string) (string, error) {
+	ctx, cancel := context.WithTimeout(c.ctx, c.con.connector.slowQueryPlanTimeout)
+	defer cancel()
+
+	plan, err := c.con.plan(ctx, query, c.con.connector.slowQueryPlanMode)
+	if err != nil {
+		return "", err
+	}
+	return plan.Text, nil
+}
+
+//redact describes each argument without its value, which may be sensitive
+func redact(args []driver.NamedValue) []string {
+	if len(args) == 0 {
+		return nil
+	}
+
+	redacted := make([]string, len(args))
+	for i, arg := range args {
+		name := fmt.Sprintf("%d", arg.Ordinal)
+		if arg.Name != "" {
+			name = arg.Name
+		}
+
+		var kind string
+		switch v := arg.Value.(type) {
+		case nil:
+			kind = "NULL"
+		case string:
+			kind = fmt.Sprintf("string(%d)", len(v))
+		case []byte:
+			kind = fmt.Sprintf("[]byte(%d)", len(v))
+		default:
+			kind = fmt.Sprintf("%T", v)
+		}
+
+		redacted[i] = name + " " + kind
+	}
+	return redacted
+}
@@ -0,0 +1,98 @@
+package sqlanywhere
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSlowQuery(t *testing.T) {
+	testdb := NewTestDB(t)
+	defer testdb.Cleanup()
+
+	var (
+		mu       sync.Mutex
+		reported []SlowQuery
+	)
+	report := func(slow SlowQuery) {
+		mu.Lock()
+		defer mu.Unlock()
+		reported = append(reported, slow)
+	}
+
+	//every statement takes at least a nanosecond
+	connector, err := NewConnector(testdb.ConnectionString(),
+		WithSlowQueryThreshold(time.Nanosecond, report),
+		WithSlowQueryPlan(ExplainShort, 0),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	const query = "select table_name from sys.systable where table_id = :id and :secret is not null"
+	rows, err := db.Query(query, sql.Named("id", 1), sql.Named("secret", "password"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	var slow *SlowQuery
+	for i := range reported {
+		if reported[i].SQL == query {
+			slow = &reported[i]
+		}
+	}
+	if slow == nil {
+		t.Fatalf("want query reported, got %+v", reported)
+	}
+
+	if slow.Duration <= 0 || slow.Err != nil {
+		t.Fatalf("want successful query timed, got %+v", slow)
+	}
+	if !reflect.DeepEqual(slow.Args, []string{"id int64", "secret string(8)"}) {
+		t.Fatalf("want redacted arguments, got %v", slow.Args)
+	}
+	if slow.PlanErr != nil || slow.Plan == "" {
+		t.Fatalf("want plan, got %q: %v", slow.Plan, slow.PlanErr)
+	}
+}
+
+func TestRedact(t *testing.T) {
+	args := []driver.NamedValue{
+		{Ordinal: 1, Value: "password"},
+		{Ordinal: 2, Name: "id", Value: int64(42)},
+		{Ordinal: 3, Value: nil},
+		{Ordinal: 4, Value: []byte{1, 2}},
+	}
+
+	redacted := redact(args)
+	want := []string{"1 string(8)", "id int64", "3 NULL", "4 []byte(2)"}
+	if !reflect.DeepEqual(redacted, want) {
+		t.Fatalf("want %v, got %v", want, redacted)
+	}
+	if strings.Contains(strings.Join(redacted, " "), "password") {
+		t.Fatal("want value redacted")
+	}
+}
+
+func TestSlowQueryPlanTimeout(t *testing.T) {
+	c := &connector{}
+	WithSlowQueryPlan(ExplainLong, 0)(c)
+	if !c.slowQueryPlan || c.slowQueryPlanMode != ExplainLong || c.slowQueryPlanTimeout != defaultSlowQueryPlanTimeout {
+		t.Fatalf("want default plan timeout, got %+v", c)
+	}
+
+	WithSlowQueryPlan(ExplainShort, 10*time.Millisecond)(c)
+	if c.slowQueryPlanTimeout != 10*time.Millisecond {
+		t.Fatalf("want plan timeout 10ms, got %v", c.slowQueryPlanTimeout)
+	}
+}
@@ -0,0 +1,160 @@
+package sqlanywhere
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+)
+
+func TestSnapshotIsolation(t *testing.T) {
+	testdb := NewTestDB(t)
+	defer testdb.Cleanup()
+
+	db, close := testdb.Open()
+	defer close()
+
+	tp := &TestPool{pool: db, t: t}
+
+	t.Run("disabled", func(t *testing.T) {
+		tp.t = t
+		testSnapshotDisabled(tp)
+	})
+
+	//without snapshot isolation, read only transactions keep the connection's isolation level,
+	//and writes are detected at commit
+	t.Run("read only with default options", func(t *testing.T) {
+		tp.t = t
+		testReadOnly(tp)
+	})
+
+	if _, err := db.Exec("SET OPTION PUBLIC.allow_snapshot_isolation = 'On'"); err != nil {
+		t.Fatalf("did not allow snapshot isolation: %v", err)
+	}
+
+	t.Run("snapshot", func(t *testing.T) {
+		tp.t = t
+		testTransactionSnapshot(tp)
+	})
+	t.Run("statement snapshot", func(t *testing.T) {
+		tp.t = t
+		testStatementSnapshot(tp, LevelStatementSnapshot)
+	})
+	t.Run("readonly statement snapshot", func(t *testing.T) {
+		tp.t = t
+		testStatementSnapshot(tp, LevelReadOnlyStatementSnapshot)
+	})
+	t.Run("read only", func(t *testing.T) {
+		tp.t = t
+		testReadOnlyEnforced(tp)
+	})
+}
+
+func testSnapshotDisabled(tp *TestPool) {
+	for _, level := range []sql.IsolationLevel{sql.LevelSnapshot, LevelStatementSnapshot, LevelReadOnlyStatementSnapshot} {
+		tx, err := tp.pool.BeginTx(context.Background(), &sql.TxOptions{Isolation: level})
+		if err != ErrSnapshotIsolationDisabled {
+			if tx != nil {
+				tx.Rollback()
+			}
+			tp.t.Fatalf("level %v: want %v, got %v", level, ErrSnapshotIsolationDisabled, err)
+		}
+	}
+}
+
+//testTransactionSnapshot checks a snapshot transaction sees the data committed when it first read,
+//not data committed since
+func testTransactionSnapshot(tp *TestPool) {
+	tp.resetAccountTable()
+
+	reader := tp.newTx(sql.LevelSnapshot)
+	defer reader.Rollback()
+
+	var balance int
+	tp.read(reader, "select balance from account where id = 1", &balance)
+
+	commitBalance(tp, 50)
+
+	tp.read(reader, "select balance from account where id = 1", &balance)
+	if balance != 100 {
+		tp.t.Fatalf("want balance 100 from snapshot, got %d", balance)
+	}
+}
+
+//testStatementSnapshot checks each statement sees the data committed when it starts,
+//without blocking on uncommitted writes
+func testStatementSnapshot(tp *TestPool, level sql.IsolationLevel) {
+	tp.resetAccountTable()
+
+	reader := tp.newTx(level)
+	defer reader.Rollback()
+
+	writer := tp.newTx(sql.LevelReadCommitted)
+	tp.write(writer, "update account set balance = 75 where id = 1")
+
+	var balance int
+	tp.read(reader, "select balance from account where id = 1", &balance)
+	if balance != 100 {
+		tp.t.Fatalf("want committed balance 100 while write is uncommitted, got %d", balance)
+	}
+
+	tp.check(writer.Commit())
+
+	tp.read(reader, "select balance from account where id = 1", &balance)
+	if balance != 75 {
+		tp.t.Fatalf("want newly committed balance 75, got %d", balance)
+	}
+}
+
+func testReadOnly(tp *TestPool) {
+	tp.resetAccountTable()
+
+	tx, err := tp.pool.BeginTx(context.Background(), &sql.TxOptions{ReadOnly: true})
+	tp.check(err)
+
+	var balance int
+	tp.read(tx, "select balance from account where id = 1", &balance)
+	tp.write(tx, "update account set balance = 0 where id = 1")
+
+	if err := tx.Commit(); err != ErrReadOnlyTransaction {
+		tp.t.Fatalf("want %v, got %v", ErrReadOnlyTransaction, err)
+	}
+
+	if err := tp.pool.QueryRow("select balance from account where id = 1").Scan(&balance); err != nil {
+		tp.t.Fatal(err)
+	}
+	if balance != 100 {
+		tp.t.Fatalf("want write in read only transaction rolled back, got balance %d", balance)
+	}
+}
+
+//testReadOnlyEnforced checks the server rejects a write in a read only transaction
+func testReadOnlyEnforced(tp *TestPool) {
+	tp.resetAccountTable()
+
+	tx, err := tp.pool.BeginTx(context.Background(), &sql.TxOptions{ReadOnly: true})
+	tp.check(err)
+	defer tx.Rollback()
+
+	var balance int
+	tp.read(tx, "select balance from account where id = 1", &balance)
+
+	if _, err := tx.Exec("update account set balance = 0 where id = 1"); err == nil {
+		tp.t.Fatal("want write in read only transaction rejected")
+	}
+	tp.check(tx.Commit())
+
+	if err := tp.pool.QueryRow("select balance from account where id = 1").Scan(&balance); err != nil {
+		tp.t.Fatal(err)
+	}
+	if balance != 100 {
+		tp.t.Fatalf("want balance 100 unchanged, got %d", balance)
+	}
+}
+
+func commitBalance(tp *TestPool, balance int) {
+	writer := tp.newTx(sql.LevelReadCommitted)
+	if _, err := writer.Exec("update account set balance = ? where id = 1", balance); err != nil {
+		tp.t.Fatal(err)
+	}
+	tp.check(writer.Commit())
+}
@@ -0,0 +1,142 @@
+package sqlanywhere
+
+import (
+	"expvar"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//DriverStats is a snapshot of the driver's activity across all connections
+type DriverStats struct {
+	//OpenConnections is the number of native connections open
+	OpenConnections int
+
+	//LiveStatements is the number of statements prepared or executed and not yet freed
+	LiveStatements int64
+
+	StatementCache StatementCacheStats
+
+	//BytesFetched is the size of the column values fetched, and BytesSent of the parameter values bound
+	BytesFetched uint64
+	BytesSent    uint64
+
+	//Cancellations is the number of requests cancelled because their context finished
+	Cancellations uint64
+
+	//Errors counts the errors returned by the server, by SQLCODE. Warnings, with positive codes, are not counted.
+	Errors map[int]uint64
+
+	//ExecLatency and QueryLatency are the durations of executing statements, and of queries until their rows are available
+	ExecLatency  Histogram
+	QueryLatency Histogram
+}
+
+//Histogram counts durations in buckets. Counts[i] is the number of durations up to Bounds[i],
+//and greater than the previous bound. The last count, beyond the last bound, is unbounded.
+type Histogram struct {
+	Bounds []time.Duration
+	Counts []uint64
+	Count  uint64
+	Sum    time.Duration
+}
+
+//latencyBounds are the upper bounds of the latency histogram buckets
+var latencyBounds = []time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+type histogram struct {
+	counts [10]uint64 //one more than the bounds
+	count  uint64
+	sum    uint64
+}
+
+func (h *histogram) observe(d time.Duration) {
+	i := 0
+	for i < len(latencyBounds) && d > latencyBounds[i] {
+		i++
+	}
+	atomic.AddUint64(&h.counts[i], 1)
+	atomic.AddUint64(&h.count, 1)
+	atomic.AddUint64(&h.sum, uint64(d))
+}
+
+func (h *histogram) snapshot() Histogram {
+	s := Histogram{
+		Bounds: latencyBounds,
+		Counts: make([]uint64, len(h.counts)),
+		Count:  atomic.LoadUint64(&h.count),
+		Sum:    time.Duration(atomic.LoadUint64(&h.sum)),
+	}
+	for i := range h.counts {
+		s.Counts[i] = atomic.LoadUint64(&h.counts[i])
+	}
+	return s
+}
+
+//metrics are the driver's activity counts, updated atomically
+type metrics struct {
+	bytesFetched  uint64
+	bytesSent     uint64
+	cancellations uint64
+	statements    int64
+	connections   int64
+
+	execLatency  histogram
+	queryLatency histogram
+
+	mu     sync.Mutex
+	errors map[int]uint64
+}
+
+var stats metrics
+
+func (m *metrics) countError(code int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.errors == nil {
+		m.errors = make(map[int]uint64)
+	}
+	m.errors[code]++
+}
+
+//Stats returns a snapshot of the driver's activity
+func Stats() DriverStats {
+	s := DriverStats{
+		OpenConnections: int(atomic.LoadInt64(&stats.connections)),
+		LiveStatements:  atomic.LoadInt64(&stats.statements),
+		StatementCache:  ReadStatementCacheStats(),
+		BytesFetched:    atomic.LoadUint64(&stats.bytesFetched),
+		BytesSent:       atomic.LoadUint64(&stats.bytesSent),
+		Cancellations:   atomic.LoadUint64(&stats.cancellations),
+		Errors:          make(map[int]uint64),
+		ExecLatency:     stats.execLatency.snapshot(),
+		QueryLatency:    stats.queryLatency.snapshot(),
+	}
+
+	stats.mu.Lock()
+	for code, n := range stats.errors {
+		s.Errors[code] = n
+	}
+	stats.mu.Unlock()
+
+	return s
+}
+
+//PublishExpvar publishes the driver's Stats under name with expvar, for example at /debug/vars.
+//Like expvar.Publish, it panics if name is already published.
+func PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return Stats()
+	}))
+}
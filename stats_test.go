@@ -0,0 +1,53 @@
+package sqlanywhere
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	testdb := NewTestDB(t)
+	defer testdb.Cleanup()
+
+	db, close := testdb.Open()
+	defer close()
+
+	before := Stats()
+
+	var s string
+	if err := db.QueryRow("select ?", "12345").Scan(&s); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("select * from no_such_table"); err == nil {
+		t.Fatal("want table not found")
+	}
+
+	after := Stats()
+
+	if after.OpenConnections < 1 {
+		t.Fatalf("want an open connection, got %d", after.OpenConnections)
+	}
+	if after.LiveStatements != before.LiveStatements {
+		t.Fatalf("want statements freed, live statements went from %d to %d", before.LiveStatements, after.LiveStatements)
+	}
+	if after.BytesSent-before.BytesSent < 5 || after.BytesFetched-before.BytesFetched < 5 {
+		t.Fatalf("want parameter sent and column fetched, got %d bytes sent and %d fetched",
+			after.BytesSent-before.BytesSent, after.BytesFetched-before.BytesFetched)
+	}
+	if after.Errors[-141] != before.Errors[-141]+1 {
+		t.Fatalf("want table not found counted, got %v", after.Errors)
+	}
+	if after.QueryLatency.Count != before.QueryLatency.Count+1 || after.ExecLatency.Count != before.ExecLatency.Count+1 {
+		t.Fatalf("want a query and an exec timed, got %+v and %+v", after.QueryLatency, after.ExecLatency)
+	}
+
+	PublishExpvar("sqlanywhere_test")
+	var published DriverStats
+	if err := json.Unmarshal([]byte(expvar.Get("sqlanywhere_test").String()), &published); err != nil {
+		t.Fatal(err)
+	}
+	if published.OpenConnections != after.OpenConnections {
+		t.Fatalf("want published stats, got %+v", published)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"sync/atomic"
 	"time"
 	"unsafe"
 )
@@ -19,9 +20,18 @@ type statement struct {
 	con                       *connection
 	ptr                       *C.a_sqlany_stmt
 	args                      []string
-	params                    []*C.a_sqlany_bind_param
+	params                    []paramBuffer
+	query                     string
 	closeStatementOnRowsClose bool
 	freed                     bool
+
+	//cached is true while the statement is in the connection's statement cache,
+	//and inUse while it is executing or has open rows
+	cached bool
+	inUse  bool
+
+	//affected is the number of rows affected by the last execution
+	affected int64
 }
 
 func (stmt *statement) Close() error {
@@ -39,7 +49,9 @@ func (stmt *statement) Close() error {
 	}
 
 	C.sqlany_free_stmt(stmt.ptr)
+	stmt.freeParams()
 	stmt.freed = true
+	atomic.AddInt64(&stats.statements, -1)
 	return nil
 }
 
@@ -47,13 +59,24 @@ func (stmt *statement) NumInput() int {
 	return int(C.sqlany_num_params(stmt.ptr))
 }
 
-func (stmt *statement) indexOfArgName(name string) int {
-	for i, arg := range stmt.args {
-		if arg == name {
-			return i + 1
+//indexes returns the zero based indexes of the parameters bound to arg.
+//A named argument is bound to each placeholder with its name.
+func (stmt *statement) indexes(arg driver.NamedValue) ([]int, error) {
+	if arg.Name == "" || len(stmt.args) == 0 {
+		return []int{arg.Ordinal - 1}, nil
+	}
+
+	var indexes []int
+	for i, name := range stmt.args {
+		if name == arg.Name {
+			indexes = append(indexes, i)
 		}
 	}
-	return -1
+
+	if len(indexes) == 0 {
+		return nil, fmt.Errorf("no parameter named %q", arg.Name)
+	}
+	return indexes, nil
 }
 
 func (stmt *statement) reset() error {
@@ -64,115 +87,209 @@ func (stmt *statement) reset() error {
 	return nil
 }
 
+//paramBuffer holds a parameter's description, made once when the statement is prepared,
+//and the C memory for its value, reused and grown across executions until the statement is closed
+type paramBuffer struct {
+	param    C.a_sqlany_bind_param
+	length   *C.size_t
+	buffer   *C.char
+	capacity int
+}
+
+//initialParamCapacity is the size of the buffer first allocated for each parameter, enough for any number.
+//Every buffer is allocated, so even an empty string or binary value is bound with a valid buffer.
+const initialParamCapacity = 8
+
+//describeParams describes the statement's parameters, allocating a buffer for each
+func (stmt *statement) describeParams() error {
+	n := int(C.sqlany_num_params(stmt.ptr))
+	if n < 0 {
+		return stmt.con.lasterr("did not get number of parameters")
+	}
+
+	stmt.params = make([]paramBuffer, n)
+	for i := range stmt.params {
+		b := &stmt.params[i]
+		if C.sqlany_describe_bind_param(stmt.ptr, C.sacapi_u32(i), &b.param) == 0 {
+			return stmt.con.lasterr(fmt.Sprintf("did not describe bind param at index %d", i))
+		}
+
+		if err := b.alloc(); err != nil {
+			return fmt.Errorf("did not allocate buffer for bind param at index %d", i)
+		}
+	}
+	return nil
+}
+
+//freeParams frees the parameter buffers
 func (stmt *statement) freeParams() {
-	for _, param := range stmt.params {
-		if param.value.length != nil {
-			C.free(unsafe.Pointer(param.value.length))
+	for i := range stmt.params {
+		stmt.params[i].free()
+	}
+	stmt.params = nil
+}
+
+//alloc allocates the buffer and its length with the initial capacity
+func (b *paramBuffer) alloc() error {
+	b.length = (*C.size_t)(C.calloc(1, C.sizeof_size_t))
+	b.buffer = (*C.char)(C.malloc(initialParamCapacity))
+	if b.length == nil || b.buffer == nil {
+		b.free()
+		return errors.New("out of memory")
+	}
+	b.capacity = initialParamCapacity
+	return nil
+}
+
+func (b *paramBuffer) free() {
+	C.free(unsafe.Pointer(b.length))
+	C.free(unsafe.Pointer(b.buffer))
+	b.length, b.buffer, b.capacity = nil, nil, 0
+}
+
+//maxParamLength is the length of the largest value bound, the most that bytes can address
+const maxParamLength = math.MaxInt32
+
+//bytes returns the first n bytes of the buffer, growing it in place if it is too small.
+//If the buffer cannot be grown it is left unchanged.
+func (b *paramBuffer) bytes(n int) ([]byte, error) {
+	if n > maxParamLength {
+		return nil, fmt.Errorf("value of %d bytes is longer than the maximum of %d", n, maxParamLength)
+	}
+
+	if n > b.capacity {
+		capacity := n
+		if capacity < 2*b.capacity && 2*b.capacity <= maxParamLength {
+			capacity = 2 * b.capacity
 		}
-		if param.value.buffer != nil {
-			C.free(unsafe.Pointer(param.value.buffer))
+
+		buffer := C.realloc(unsafe.Pointer(b.buffer), C.size_t(capacity))
+		if buffer == nil {
+			return nil, fmt.Errorf("did not allocate %d bytes", capacity)
 		}
+		b.buffer = (*C.char)(buffer)
+		b.capacity = capacity
 	}
 
-	stmt.params = stmt.params[:0]
+	*b.length = C.size_t(n)
+	return (*[maxParamLength]byte)(unsafe.Pointer(b.buffer))[:n:n], nil
 }
 
 func (stmt *statement) exec(args []driver.NamedValue) error {
+	for _, arg := range args {
+		indexes, err := stmt.indexes(arg)
+		if err != nil {
+			return err
+		}
 
-	if len(stmt.args) > 0 {
-		for i := 0; i < len(args); i++ {
-			v := args[i] //watch out! variable shadowing
-			if v.Name != "" {
-
-				ordinal := stmt.indexOfArgName(v.Name)
-				if ordinal > 0 {
-					args[i].Ordinal = ordinal //watch out!
-				}
+		for _, index := range indexes {
+			if err := stmt.bind(index, arg.Value); err != nil {
+				return err
 			}
 		}
 	}
 
-	defer stmt.freeParams()
+	if C.sqlany_execute(stmt.ptr) == 0 {
+		return stmt.con.lasterr("did not exec")
+	}
+	stmt.affected = affectedRows(stmt.ptr)
 
-	for _, namedValue := range args {
-		index := namedValue.Ordinal - 1
-		value := namedValue.Value
+	return nil
+}
 
-		cindex := C.sacapi_u32(index)
+//bind binds the value to the parameter at the zero based index
+func (stmt *statement) bind(index int, value driver.Value) error {
+	if index < 0 || index >= len(stmt.params) {
+		return fmt.Errorf("no parameter at index %d", index)
+	}
 
-		param := new(C.a_sqlany_bind_param)
+	b := &stmt.params[index]
 
-		if C.sqlany_describe_bind_param(stmt.ptr, cindex, param) == 0 {
-			return stmt.con.lasterr("did not describe bind param: ")
-		}
+	//bind a copy of the description, so each execution starts from the described type
+	param := b.param
+	param.value.length = b.length
+	param.value.buffer = b.buffer
 
-		if value == nil {
-			param.value.is_null = &C.YES
-			if C.sqlany_bind_param(stmt.ptr, cindex, param) == 0 {
-				return stmt.con.lasterr(fmt.Sprintf("did not bind parameter at index %d", cindex))
-			}
-
-			return nil
-		}
+	if value == nil {
+		//bind NULL with the type described for the parameter
+		param.value.is_null = &C.YES
+	} else {
 		param.value.is_null = &C.NO
 
-		stmt.params = append(stmt.params, param)
-
-		param.value.length = (*C.size_t)(unsafe.Pointer(C.calloc(1, C.sizeof_size_t))) //must free later
+		if err := b.setValue(&param, value); err != nil {
+			return fmt.Errorf("did not create param at index %d: %v", index, err)
+		}
+		atomic.AddUint64(&stats.bytesSent, uint64(*b.length))
+	}
 
-		switch v := value.(type) {
-		case bool:
-			param.value._type = C.A_VAL8
-			*param.value.length = 1
-			param.value.buffer = (*C.char)(unsafe.Pointer(C.calloc(1, 1))) //zeroed = false by default
+	if C.sqlany_bind_param(stmt.ptr, C.sacapi_u32(index), &param) == 0 {
+		return stmt.con.lasterr(fmt.Sprintf("did not bind parameter at index %d", index))
+	}
+	return nil
+}
 
+//setValue writes the value into the buffer, setting the parameter's type, length and buffer
+func (b *paramBuffer) setValue(param *C.a_sqlany_bind_param, value driver.Value) error {
+	var err error
+	switch v := value.(type) {
+	case bool:
+		param.value._type = C.A_VAL8
+		var buf []byte
+		if buf, err = b.bytes(1); err == nil {
+			buf[0] = 0
 			if v {
-				*param.value.buffer = 1
+				buf[0] = 1
 			}
+		}
 
-		case int64:
-			param.value._type = C.A_VAL64
-			*param.value.length = 8
-			var buf [8]byte
-			binary.LittleEndian.PutUint64(buf[:], uint64(v))
-			param.value.buffer = (*C.char)(C.CBytes(buf[:]))
-
-		case float64:
-			param.value._type = C.A_DOUBLE
-			*param.value.length = 8
-			var buf [8]byte
-			binary.LittleEndian.PutUint64(buf[:], math.Float64bits(v))
-			param.value.buffer = (*C.char)(C.CBytes(buf[:]))
-
-		case string:
-			param.value._type = C.A_STRING
-			*param.value.length = C.size_t(len(v))
-			param.value.buffer = C.CString(v)
-
-		case []byte:
-			param.value._type = C.A_BINARY
-			*param.value.length = C.size_t(len(v))
-			param.value.buffer = (*C.char)(C.CBytes(v))
-
-		case time.Time:
-			param.value._type = C.A_STRING
-			s := timeToString(v)
-			*param.value.length = C.size_t(len(s))
-			param.value.buffer = (*C.char)(C.CString(s))
-
-		default:
-			return fmt.Errorf("did not create param at index %d: no binding for value type: %T", index, value)
+	case int64:
+		param.value._type = C.A_VAL64
+		var buf []byte
+		if buf, err = b.bytes(8); err == nil {
+			binary.LittleEndian.PutUint64(buf, uint64(v))
 		}
 
-		if C.sqlany_bind_param(stmt.ptr, cindex, param) == 0 {
-			return stmt.con.lasterr(fmt.Sprintf("did not bind parameter at index %d", index))
+	case float64:
+		param.value._type = C.A_DOUBLE
+		var buf []byte
+		if buf, err = b.bytes(8); err == nil {
+			binary.LittleEndian.PutUint64(buf, math.Float64bits(v))
 		}
-	}
 
-	if C.sqlany_execute(stmt.ptr) == 0 {
-		return stmt.con.lasterr("did not exec")
+	case string:
+		param.value._type = C.A_STRING
+		err = b.copyString(v)
+
+	case []byte:
+		param.value._type = C.A_BINARY
+		var buf []byte
+		if buf, err = b.bytes(len(v)); err == nil {
+			copy(buf, v)
+		}
+
+	case time.Time:
+		param.value._type = C.A_STRING
+		err = b.copyString(timeToString(v))
+
+	default:
+		return fmt.Errorf("no binding for value type: %T", value)
+	}
+	if err != nil {
+		return err
 	}
 
+	//the buffer may have moved when grown
+	param.value.buffer = b.buffer
+	return nil
+}
+
+//copyString copies the string into the buffer
+func (b *paramBuffer) copyString(s string) error {
+	buf, err := b.bytes(len(s))
+	if err != nil {
+		return err
+	}
+	copy(buf, s)
 	return nil
 }
 
@@ -185,38 +302,48 @@ func asNamedArgs(args []driver.Value) []driver.NamedValue {
 }
 
 func (stmt *statement) Exec(args []driver.Value) (driver.Result, error) {
-
-	var r *result
-	named := asNamedArgs(args)
-	if err := stmt.exec(named); err != nil {
-		return r, err
-	}
-	r = &result{stmt: stmt}
-	return r, nil
+	return stmt.ExecContext(context.Background(), stmt.query, asNamedArgs(args))
 }
 
 func (stmt *statement) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if err := stmt.setQueryTimeout(ctx); err != nil {
+		return nil, err
+	}
+
+	trace := stmt.con.startTrace(ctx, OpExec, stmt.query, len(args))
+	start := time.Now()
 
 	err := stmt.con.awaitFunc(ctx, func() error {
 		return stmt.exec(args)
 	})
-	return &result{stmt: stmt}, err
+	stats.execLatency.observe(time.Since(start))
+	if err != nil {
+		trace.end(0, err)
+		return nil, err
+	}
+
+	trace.end(stmt.affected, nil)
+	return &result{con: stmt.con, rowsAffected: stmt.affected}, nil
 }
 
 func (stmt *statement) Query(args []driver.Value) (driver.Rows, error) {
-	named := asNamedArgs(args)
-	if err := stmt.exec(named); err != nil {
-		return nil, err
-	}
-	return stmt.newRows(context.Background())
+	return stmt.QueryContext(context.Background(), asNamedArgs(args))
 }
 
 func (stmt *statement) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	if err := stmt.setQueryTimeout(ctx); err != nil {
+		return nil, err
+	}
+
+	trace := stmt.con.startTrace(ctx, OpQuery, stmt.query, len(args))
+	start := time.Now()
 
 	err := stmt.con.awaitFunc(ctx, func() error {
 		return stmt.exec(args)
 	})
+	stats.queryLatency.observe(time.Since(start))
 
+	trace.end(0, err)
 	if err != nil {
 		return nil, err
 	}
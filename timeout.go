@@ -0,0 +1,73 @@
+package sqlanywhere
+
+//#include <driver.c>
+import "C"
+import (
+	"context"
+	"math"
+	"strconv"
+	"time"
+)
+
+//timeoutSeconds converts the context deadline into a whole number of seconds for the server,
+//rounding up. Zero means the context has no deadline.
+func timeoutSeconds(ctx context.Context) int {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0
+	}
+
+	seconds := int(math.Ceil(time.Until(deadline).Seconds()))
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
+
+//needsTimeout reports whether a server timeout of current seconds should be changed to want seconds.
+//Server timeouts only back up cancellation via the context, so a current timeout that is no shorter
+//than wanted, and not excessively longer, is kept to save a round trip.
+func needsTimeout(current, want int) bool {
+	if want == 0 || current == 0 {
+		return current != want
+	}
+	return current < want || current > 2*want
+}
+
+//setRequestTimeout sets the request_timeout option from the context deadline, so the server aborts
+//requests that outlive it. It applies to statements executed without a prepared statement.
+func (con *connection) setRequestTimeout(ctx context.Context) error {
+	seconds := timeoutSeconds(ctx)
+	if !needsTimeout(con.requestTimeout, seconds) {
+		return nil
+	}
+
+	if seconds == 0 {
+		if err := con.restoreOption("request_timeout"); err != nil {
+			return err
+		}
+		con.requestTimeout = 0
+		return nil
+	}
+
+	if err := con.setTemporaryOption("request_timeout", strconv.Itoa(seconds)); err != nil {
+		return err
+	}
+	con.requestTimeout = seconds
+	return nil
+}
+
+//setQueryTimeout sets the query timeout from the context deadline, so the server aborts the
+//statement if it outlives it. The client library applies the timeout to all statements of the connection.
+func (stmt *statement) setQueryTimeout(ctx context.Context) error {
+	seconds := timeoutSeconds(ctx)
+	if !needsTimeout(stmt.con.queryTimeout, seconds) {
+		return nil
+	}
+
+	if C.sqlany_set_query_timeout(stmt.ptr, C.sacapi_i32(seconds)) == 0 {
+		return stmt.con.lasterr("did not set query timeout")
+	}
+	stmt.con.queryTimeout = seconds
+	return nil
+}
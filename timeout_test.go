@@ -0,0 +1,83 @@
+package sqlanywhere
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDeadline(t *testing.T) {
+	testdb := NewTestDB(t)
+	defer testdb.Cleanup()
+
+	db, close := testdb.Open()
+	defer close()
+
+	//a single connection, so the connection timed out is the one used afterwards
+	db.SetMaxOpenConns(1)
+
+	const deadline = 1500 * time.Millisecond
+	const prompt = deadline + time.Second
+
+	tests := []struct {
+		name  string
+		query string
+		args  []interface{}
+	}{
+		{"immediate", "WAITFOR DELAY '00:00:10'", nil},
+		{"prepared", "WAITFOR DELAY ?", []interface{}{"00:00:10"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), deadline)
+			defer cancel()
+
+			start := time.Now()
+			_, err := db.ExecContext(ctx, test.query, test.args...)
+			elapsed := time.Since(start)
+
+			if err != context.DeadlineExceeded {
+				t.Fatalf("want %v, got %v", context.DeadlineExceeded, err)
+			}
+			if elapsed > prompt {
+				t.Fatalf("want return within %v, took %v", prompt, elapsed)
+			}
+
+			var one int
+			if err := db.QueryRow("select 1").Scan(&one); err != nil || one != 1 {
+				t.Fatalf("connection unusable after deadline: %v", err)
+			}
+		})
+	}
+}
+
+func TestTimeoutSeconds(t *testing.T) {
+	if got := timeoutSeconds(context.Background()); got != 0 {
+		t.Fatalf("want 0 without deadline, got %d", got)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1500*time.Millisecond)
+	defer cancel()
+	if got := timeoutSeconds(ctx); got != 2 {
+		t.Fatalf("want deadline rounded up to 2 seconds, got %d", got)
+	}
+
+	tests := []struct {
+		current, want int
+		needed        bool
+	}{
+		{0, 0, false},
+		{0, 5, true},
+		{5, 0, true},
+		{5, 5, false},
+		{4, 5, true},
+		{10, 5, false},
+		{11, 5, true},
+	}
+	for _, test := range tests {
+		if got := needsTimeout(test.current, test.want); got != test.needed {
+			t.Errorf("needsTimeout(%d, %d): want %v, got %v", test.current, test.want, test.needed, got)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package sqlanywhere
+
+import (
+	"context"
+	"time"
+)
+
+//Op identifies a driver operation reported to a Tracer
+type Op int
+
+const (
+	OpConnect Op = iota
+	OpPrepare
+	OpExec
+	OpQuery
+	OpFetch
+	OpBegin
+	OpCommit
+	OpRollback
+	OpClose
+)
+
+var opNames = [...]string{"connect", "prepare", "exec", "query", "fetch", "begin", "commit", "rollback", "close"}
+
+func (op Op) String() string {
+	if op < 0 || int(op) >= len(opNames) {
+		return "unknown"
+	}
+	return opNames[op]
+}
+
+//fetchBatch is the number of rows fetched between trace events, reported as a single fetch
+const fetchBatch = 100
+
+//TraceEvent describes a driver operation. The results are set when the operation ends.
+type TraceEvent struct {
+	Op Op
+
+	//SQL is the statement prepared, executed or fetched from, if any
+	SQL string
+
+	//Args is the number of arguments bound
+	Args int
+
+	//Rows is the number of rows affected by an exec, or fetched by a fetch
+	Rows int64
+
+	//Code is the SQLCODE of the error, zero if none or if the error is not from the server
+	Code int
+
+	Err      error
+	Duration time.Duration
+}
+
+//Tracer observes driver operations, set with WithTracer.
+//Calls may be made concurrently for different connections.
+type Tracer interface {
+	//TraceStart is called as an operation starts. The context returned is passed to TraceEnd,
+	//so a tracer can carry state, such as a span, between the calls.
+	TraceStart(ctx context.Context, event TraceEvent) context.Context
+
+	//TraceEnd is called as an operation ends, with its results and duration
+	TraceEnd(ctx context.Context, event TraceEvent)
+}
+
+//trace is an operation in progress. A nil trace, returned when tracing is disabled, does nothing.
+type trace struct {
+	tracer Tracer
+	ctx    context.Context
+	event  TraceEvent
+	start  time.Time
+}
+
+//startTrace reports an operation starting on the connection, returning nil if tracing is disabled
+func (con *connection) startTrace(ctx context.Context, op Op, sql string, args int) *trace {
+	if con.connector == nil || con.connector.tracer == nil {
+		return nil
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	t := &trace{tracer: con.connector.tracer, event: TraceEvent{Op: op, SQL: sql, Args: args}}
+	t.ctx = t.tracer.TraceStart(ctx, t.event)
+	t.start = time.Now()
+	return t
+}
+
+//end reports the operation ending, with the rows affected or fetched and error, if any
+func (t *trace) end(rows int64, err error) {
+	if t == nil {
+		return
+	}
+
+	t.event.Duration = time.Since(t.start)
+	t.event.Rows = rows
+	t.event.Err = err
+	if de, ok := err.(*DriverError); ok {
+		t.event.Code = de.code
+	}
+
+	t.tracer.TraceEnd(t.ctx, t.event)
+}
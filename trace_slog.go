@@ -0,0 +1,58 @@
+//go:build go1.21
+// +build go1.21
+
+package sqlanywhere
+
+import (
+	"context"
+	"log/slog"
+)
+
+//SlogTracer is a Tracer emitting a log/slog record as each operation ends.
+//Successful operations are logged at Level, failed ones at slog.LevelError.
+type SlogTracer struct {
+	Logger *slog.Logger
+	Level  slog.Level
+}
+
+//NewSlogTracer returns a tracer logging to logger at debug level, or to slog.Default if logger is nil
+func NewSlogTracer(logger *slog.Logger) *SlogTracer {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &SlogTracer{Logger: logger, Level: slog.LevelDebug}
+}
+
+func (s *SlogTracer) TraceStart(ctx context.Context, event TraceEvent) context.Context {
+	return ctx
+}
+
+func (s *SlogTracer) TraceEnd(ctx context.Context, event TraceEvent) {
+	level := s.Level
+	if event.Err != nil {
+		level = slog.LevelError
+	}
+
+	if !s.Logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("op", event.Op.String()),
+		slog.Duration("duration", event.Duration),
+	}
+	if event.SQL != "" {
+		attrs = append(attrs, slog.String("sql", event.SQL))
+	}
+	if event.Args > 0 {
+		attrs = append(attrs, slog.Int("args", event.Args))
+	}
+	if event.Op == OpExec || event.Op == OpFetch {
+		attrs = append(attrs, slog.Int64("rows", event.Rows))
+	}
+	if event.Err != nil {
+		attrs = append(attrs, slog.Int("sqlcode", event.Code), slog.String("error", event.Err.Error()))
+	}
+
+	s.Logger.LogAttrs(ctx, level, "sqlanywhere "+event.Op.String(), attrs...)
+}
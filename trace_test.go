@@ -0,0 +1,115 @@
+package sqlanywhere
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"testing"
+)
+
+//recordingTracer records the events ended
+type recordingTracer struct {
+	mu     sync.Mutex
+	events []TraceEvent
+}
+
+func (r *recordingTracer) TraceStart(ctx context.Context, event TraceEvent) context.Context {
+	return ctx
+}
+
+func (r *recordingTracer) TraceEnd(ctx context.Context, event TraceEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+//find returns the events for op with sql
+func (r *recordingTracer) find(op Op, sql string) []TraceEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var found []TraceEvent
+	for _, event := range r.events {
+		if event.Op == op && event.SQL == sql {
+			found = append(found, event)
+		}
+	}
+	return found
+}
+
+func TestTracer(t *testing.T) {
+	testdb := NewTestDB(t)
+	defer testdb.Cleanup()
+
+	tracer := &recordingTracer{}
+	connector, err := NewConnector(testdb.ConnectionString(), WithTracer(tracer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := sql.OpenDB(connector)
+
+	const create = "create table trace_test (id int primary key)"
+	if _, err := db.Exec(create); err != nil {
+		t.Fatal(err)
+	}
+
+	const insert = "insert into trace_test values (?)"
+	for i := 0; i < fetchBatch+1; i++ {
+		if _, err := db.Exec(insert, i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	const query = "select id from trace_test"
+	rows, err := db.Query(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+	}
+	if err := rows.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.Exec("insert into trace_test values (?)", 0); err == nil {
+		t.Fatal("want duplicate key error")
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if events := tracer.find(OpExec, create); len(events) != 1 || events[0].Duration <= 0 {
+		t.Fatalf("want one timed exec of create, got %+v", events)
+	}
+
+	inserts := tracer.find(OpExec, insert)
+	if len(inserts) != fetchBatch+2 || inserts[0].Args != 1 || inserts[0].Rows != 1 {
+		t.Fatalf("want each insert traced with 1 argument and 1 row affected, got %+v", inserts)
+	}
+	if failed := inserts[len(inserts)-1]; failed.Err == nil || failed.Code == 0 {
+		t.Fatalf("want duplicate key traced with SQLCODE, got %+v", failed)
+	}
+
+	var fetched int64
+	for _, event := range tracer.find(OpFetch, query) {
+		fetched += event.Rows
+	}
+	if fetched != fetchBatch+1 {
+		t.Fatalf("want %d rows fetched, got %d", fetchBatch+1, fetched)
+	}
+
+	for _, op := range []Op{OpConnect, OpQuery, OpBegin, OpCommit, OpClose} {
+		if len(tracer.find(op, "")) == 0 && len(tracer.find(op, query)) == 0 {
+			t.Fatalf("want %v traced", op)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package sqlanywhere
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+//FileTransferPolicy decides whether the client file may be transferred. write is true when
+//the server writes the file, as for UNLOAD, and false when it reads it, as for LOAD TABLE.
+type FileTransferPolicy func(file string, write bool) bool
+
+//AllowFileTransfersIn is a FileTransferPolicy permitting transfers of files within the directories
+func AllowFileTransfersIn(dirs ...string) FileTransferPolicy {
+	return func(file string, write bool) bool {
+		file, err := filepath.Abs(file)
+		if err != nil {
+			return false
+		}
+
+		for _, dir := range dirs {
+			dir, err := filepath.Abs(dir)
+			if err != nil {
+				continue
+			}
+			if rel, err := filepath.Rel(dir, file); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+//validateFileTransfer applies the file transfer policy of the connection with the handle.
+//It is called from the client library on the goroutine making the request; a panicking policy denies the transfer.
+func validateFileTransfer(handle uintptr, file string, write bool) (allowed bool) {
+	con, ok := handleConnection(handle)
+	if !ok || con.connector == nil || con.connector.fileTransferPolicy == nil {
+		return false
+	}
+
+	defer func() {
+		if recover() != nil {
+			allowed = false
+		}
+	}()
+	return con.connector.fileTransferPolicy(file, write)
+}
+
+//TextFormat describes the text files transferred by LoadTable and UnloadQuery.
+//Empty fields use the server's defaults: comma delimited, newline terminated rows,
+//strings quoted with apostrophes and backslash escapes.
+type TextFormat struct {
+	//Delimiter separates columns, and RowDelimiter rows
+	Delimiter    string
+	RowDelimiter string
+
+	//Quote is the character quoting strings. NoQuotes leaves strings unquoted.
+	Quote    string
+	NoQuotes bool
+
+	//NoEscapes disables backslash escapes
+	NoEscapes bool
+
+	//Encoding is the character set of the file, such as UTF-8
+	Encoding string
+}
+
+func (f TextFormat) clauses() []string {
+	var clauses []string
+	if f.Delimiter != "" {
+		clauses = append(clauses, "DELIMITED BY "+quoteString(f.Delimiter))
+	}
+	if f.RowDelimiter != "" {
+		clauses = append(clauses, "ROW DELIMITED BY "+quoteString(f.RowDelimiter))
+	}
+	if f.Quote != "" {
+		clauses = append(clauses, "QUOTE "+quoteString(f.Quote))
+	}
+	if f.NoQuotes {
+		clauses = append(clauses, "QUOTES OFF")
+	}
+	if f.NoEscapes {
+		clauses = append(clauses, "ESCAPES OFF")
+	}
+	if f.Encoding != "" {
+		clauses = append(clauses, "ENCODING "+quoteString(f.Encoding))
+	}
+	return clauses
+}
+
+//LoadOptions configure LoadTable
+type LoadOptions struct {
+	TextFormat
+
+	//Columns are the columns loaded, in the order of the file's fields. Empty loads all columns in table order.
+	Columns []string
+
+	//Skip ignores the first lines of the file, such as a header
+	Skip int
+
+	//Defaults fills columns missing from the file with their default values
+	Defaults bool
+}
+
+//UnloadOptions configure UnloadQuery
+type UnloadOptions struct {
+	TextFormat
+}
+
+//LoadTable bulk loads rows read from r into the table, with LOAD TABLE ... USING CLIENT FILE.
+//The rows are staged in a temporary file, which the server reads through the connection.
+//The table is named as in SQL, quoted as required, for example `"GROUPO"."Customers"`.
+//
+//Client file transfers require the database's allow_read_client_file option to be on,
+//and the user to have the READ CLIENT FILE privilege.
+func LoadTable(ctx context.Context, conn Execer, table string, r io.Reader, opts LoadOptions) error {
+	file, err := ioutil.TempFile("", "sqlanywhere-load-*")
+	if err != nil {
+		return fmt.Errorf("did not create load file: %v", err)
+	}
+	defer os.Remove(file.Name())
+
+	_, err = io.Copy(file, r)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("did not write load file: %v", err)
+	}
+
+	if _, err := conn.ExecContext(ctx, loadStatement(table, file.Name(), opts)); err != nil {
+		return fmt.Errorf("did not load %s: %w", table, err)
+	}
+	return nil
+}
+
+func loadStatement(table, file string, opts LoadOptions) string {
+	var b strings.Builder
+	b.WriteString("LOAD TABLE ")
+	b.WriteString(table)
+	if len(opts.Columns) > 0 {
+		quoted := make([]string, len(opts.Columns))
+		for i, column := range opts.Columns {
+			quoted[i] = quoteIdentifier(column)
+		}
+		b.WriteString(" (" + strings.Join(quoted, ", ") + ")")
+	}
+	b.WriteString(" USING CLIENT FILE " + quoteString(file))
+
+	clauses := opts.clauses()
+	if opts.Skip > 0 {
+		clauses = append(clauses, fmt.Sprintf("SKIP %d", opts.Skip))
+	}
+	if opts.Defaults {
+		clauses = append(clauses, "DEFAULTS ON")
+	}
+	for _, clause := range clauses {
+		b.WriteString(" " + clause)
+	}
+	return b.String()
+}
+
+//UnloadQuery writes the rows of the query to w, with UNLOAD ... INTO CLIENT FILE.
+//The server writes the rows through the connection to a temporary file, which is then copied to w.
+//
+//Client file transfers require the database's allow_write_client_file option to be on,
+//and the user to have the WRITE CLIENT FILE privilege.
+func UnloadQuery(ctx context.Context, conn Execer, query string, w io.Writer, opts UnloadOptions) error {
+	file, err := ioutil.TempFile("", "sqlanywhere-unload-*")
+	if err != nil {
+		return fmt.Errorf("did not create unload file: %v", err)
+	}
+	name := file.Name()
+	file.Close()
+	defer os.Remove(name)
+
+	if _, err := conn.ExecContext(ctx, unloadStatement(query, name, opts)); err != nil {
+		return fmt.Errorf("did not unload: %w", err)
+	}
+
+	file, err = os.Open(name)
+	if err != nil {
+		return fmt.Errorf("did not open unload file: %v", err)
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(w, file); err != nil {
+		return fmt.Errorf("did not copy unload file: %v", err)
+	}
+	return nil
+}
+
+func unloadStatement(query, file string, opts UnloadOptions) string {
+	statement := "UNLOAD " + query + " INTO CLIENT FILE " + quoteString(file)
+	for _, clause := range opts.clauses() {
+		statement += " " + clause
+	}
+	return statement
+}
+
+//quoteString returns s as a string literal, with apostrophes doubled and backslashes escaped
+func quoteString(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", "''").Replace(s) + "'"
+}
+
+//quoteIdentifier returns s as a quoted identifier
+func quoteIdentifier(s string) string {
+	return `"` + strings.Replace(s, `"`, `""`, -1) + `"`
+}
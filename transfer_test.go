@@ -0,0 +1,174 @@
+package sqlanywhere
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestLoadUnload(t *testing.T) {
+	testdb := NewTestDB(t)
+	defer testdb.Cleanup()
+
+	db, close := testdb.Open()
+	defer close()
+
+	execStatements(t, db,
+		"set option public.allow_read_client_file = 'On'",
+		"set option public.allow_write_client_file = 'On'",
+		"create table transfer_test (id int primary key, name varchar(20) null, note varchar(20) default 'none')",
+	)
+
+	ctx := context.Background()
+
+	input := "id|name\n1|'a|b'\n2|'it''s'\n3|\n"
+	err := LoadTable(ctx, db, "transfer_test", strings.NewReader(input), LoadOptions{
+		TextFormat: TextFormat{Delimiter: "|"},
+		Columns:    []string{"id", "name"},
+		Skip:       1,
+		Defaults:   true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count := countRows(t, db, "transfer_test"); count != 3 {
+		t.Fatalf("want 3 rows loaded, got %d", count)
+	}
+
+	var output bytes.Buffer
+	err = UnloadQuery(ctx, db, "select id, name, note from transfer_test order by id", &output, UnloadOptions{
+		TextFormat: TextFormat{Delimiter: "|"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "1|'a|b'|'none'\n2|'it''s'|'none'\n3||'none'\n"
+	if output.String() != want {
+		t.Fatalf("want unloaded\n%q\ngot\n%q", want, output.String())
+	}
+
+	if err := LoadTable(ctx, db, "transfer_test", strings.NewReader("1,'duplicate'\n"), LoadOptions{Columns: []string{"id", "name"}}); err == nil {
+		t.Fatal("want error loading duplicate key")
+	}
+}
+
+func TestFileTransferPolicy(t *testing.T) {
+	testdb := NewTestDB(t)
+	defer testdb.Cleanup()
+
+	db, close := testdb.Open()
+	defer close()
+
+	execStatements(t, db,
+		"set option public.allow_read_client_file = 'On'",
+		"create table policy_test (id int primary key)",
+		"create procedure load_policy_test(in file_name long varchar) begin load table policy_test using client file file_name; end",
+	)
+
+	dir, err := ioutil.TempDir("", "sqlanywhere-policy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	allowed := filepath.Join(dir, "allowed.txt")
+	if err := ioutil.WriteFile(allowed, []byte("1\n2\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	denied, err := ioutil.TempFile("", "sqlanywhere-denied-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	denied.WriteString("3\n")
+	denied.Close()
+	defer os.Remove(denied.Name())
+
+	for _, test := range cancelStrategies {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				mu        sync.Mutex
+				validated []string
+			)
+			policy := AllowFileTransfersIn(dir)
+			connector, err := NewConnector(testdb.ConnectionString(),
+				WithCancelStrategy(test.strategy),
+				WithFileTransferPolicy(func(file string, write bool) bool {
+					mu.Lock()
+					validated = append(validated, file)
+					mu.Unlock()
+					return !write && policy(file, write)
+				}),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pool := sql.OpenDB(connector)
+			defer pool.Close()
+
+			if _, err := pool.Exec("delete from policy_test"); err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			if _, err := pool.ExecContext(ctx, "call load_policy_test(?)", allowed); err != nil {
+				t.Fatalf("want load permitted by policy, got %v", err)
+			}
+			if _, err := pool.Exec("call load_policy_test(?)", denied.Name()); err == nil {
+				t.Fatal("want load denied by policy")
+			}
+
+			if count := countRows(t, pool, "policy_test"); count != 2 {
+				t.Fatalf("want 2 rows loaded, got %d", count)
+			}
+			if len(validated) != 2 {
+				t.Fatalf("want 2 transfers validated, got %v", validated)
+			}
+		})
+	}
+}
+
+func TestAllowFileTransfersIn(t *testing.T) {
+	policy := AllowFileTransfersIn("/data/load")
+
+	for file, want := range map[string]bool{
+		"/data/load/a.csv":        true,
+		"/data/load/sub/a.csv":    true,
+		"/data/load/../a.csv":     false,
+		"/data/loader/a.csv":      false,
+		"/etc/passwd":             false,
+		"/data/load/..hidden.csv": true,
+	} {
+		if got := policy(file, false); got != want {
+			t.Errorf("%s: want %v, got %v", file, want, got)
+		}
+	}
+}
+
+func TestLoadStatement(t *testing.T) {
+	got := loadStatement(`"dba"."t"`, "/tmp/it's", LoadOptions{
+		TextFormat: TextFormat{Delimiter: `\t`, NoQuotes: true, Encoding: "UTF-8"},
+		Columns:    []string{"a", `b"c`},
+		Skip:       1,
+	})
+	want := `LOAD TABLE "dba"."t" ("a", "b""c") USING CLIENT FILE '/tmp/it''s' DELIMITED BY '\\t' QUOTES OFF ENCODING 'UTF-8' SKIP 1`
+	if got != want {
+		t.Fatalf("want\n%s\ngot\n%s", want, got)
+	}
+}
+
+// execStatements executes each statement, failing the test at the first error
+func execStatements(t *testing.T, db *sql.DB, statements ...string) {
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatalf("did not exec %q: %v", statement, err)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package sqlanywhere
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+//TwoPhaseConn is implemented by the driver's connections, reachable with sql.Conn.Raw
+type TwoPhaseConn interface {
+	//PrepareTransaction prepares the connection's outstanding transaction to commit
+	PrepareTransaction(ctx context.Context) error
+}
+
+//ErrNoTransaction is returned when preparing to commit on a connection without an outstanding transaction
+var ErrNoTransaction = errors.New("no outstanding transaction")
+
+//Prepare executes PREPARE TO COMMIT, the first phase of a two phase commit. Once it succeeds,
+//a commit of the transaction will succeed. The transaction must then be committed or rolled back.
+func (t *tx) Prepare(ctx context.Context) error {
+	if t.state != txActive {
+		return sql.ErrTxDone
+	}
+
+	return t.con.awaitFunc(ctx, func() error {
+		return t.con.execImmediate("PREPARE TO COMMIT")
+	})
+}
+
+//PrepareTransaction prepares the connection's outstanding transaction to commit, implementing TwoPhaseConn
+func (con *connection) PrepareTransaction(ctx context.Context) error {
+	if con.tx == nil {
+		return ErrNoTransaction
+	}
+	return con.tx.Prepare(ctx)
+}
+
+//PrepareTx prepares the transaction outstanding on conn to commit
+func PrepareTx(ctx context.Context, conn *sql.Conn) error {
+	return conn.Raw(func(driverConn interface{}) error {
+		twoPhase, ok := driverConn.(TwoPhaseConn)
+		if !ok {
+			return fmt.Errorf("connection does not support two phase commit: %T", driverConn)
+		}
+		return twoPhase.PrepareTransaction(ctx)
+	})
+}
+
+//Participant is a transaction in a two phase commit, begun with Conn.BeginTx
+type Participant struct {
+	Conn *sql.Conn
+	Tx   *sql.Tx
+}
+
+//CommitError reports a two phase commit that did not complete, and what remains to be done.
+type CommitError struct {
+	//Prepared is true if every participant prepared, so the outcome was to commit
+	Prepared bool
+
+	//Failed is the index of the participant that failed first, and Err its error
+	Failed int
+	Err    error
+
+	//Committed are the indexes of committed participants. InDoubt are the indexes of
+	//prepared participants that did not commit; they hold their locks until resolved.
+	Committed []int
+	InDoubt   []int
+}
+
+func (err *CommitError) Error() string {
+	if !err.Prepared {
+		return fmt.Sprintf("two phase commit: participant %d did not prepare, all participants rolled back: %v", err.Failed, err.Err)
+	}
+	return fmt.Sprintf("two phase commit: participant %d did not commit after all prepared: %v: "+
+		"participants %v committed, participants %v are in doubt and must be committed to complete the transaction",
+		err.Failed, err.Err, err.Committed, err.InDoubt)
+}
+
+func (err *CommitError) Unwrap() error {
+	return err.Err
+}
+
+//TwoPhaseCommit prepares each participant's transaction to commit. If all prepare, they are all committed.
+//Otherwise they are all rolled back. The error is a *CommitError describing any participants left in doubt.
+func TwoPhaseCommit(ctx context.Context, participants ...Participant) error {
+	for i, p := range participants {
+		if err := PrepareTx(ctx, p.Conn); err != nil {
+			for _, p := range participants {
+				p.Tx.Rollback()
+			}
+			return &CommitError{Failed: i, Err: err}
+		}
+	}
+
+	//every participant is prepared, so the outcome is to commit: try every participant even if one fails
+	var commitErr *CommitError
+	for i, p := range participants {
+		err := p.Tx.Commit()
+
+		if err == nil {
+			if commitErr != nil {
+				commitErr.Committed = append(commitErr.Committed, i)
+			}
+			continue
+		}
+
+		if commitErr == nil {
+			commitErr = &CommitError{Prepared: true, Failed: i, Err: err}
+			for committed := 0; committed < i; committed++ {
+				commitErr.Committed = append(commitErr.Committed, committed)
+			}
+		}
+		commitErr.InDoubt = append(commitErr.InDoubt, i)
+	}
+
+	if commitErr != nil {
+		return commitErr
+	}
+	return nil
+}
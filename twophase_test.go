@@ -0,0 +1,108 @@
+package sqlanywhere
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+)
+
+func TestTwoPhaseCommit(t *testing.T) {
+	first := NewTestDB(t)
+	defer first.Cleanup()
+
+	second := NewTestDB(t)
+	defer second.Cleanup()
+
+	firstDB, closeFirst := first.Open()
+	defer closeFirst()
+
+	secondDB, closeSecond := second.Open()
+	defer closeSecond()
+
+	for _, db := range []*sql.DB{firstDB, secondDB} {
+		if _, err := db.Exec(`create table parent (id int primary key)`); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.Exec(`create table child (id int primary key, parent_id int references parent(id))`); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.Exec(`insert into parent values (1)`); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx := context.Background()
+
+	t.Run("commit", func(t *testing.T) {
+		a := beginParticipant(t, firstDB, "insert into child values (1, 1)")
+		b := beginParticipant(t, secondDB, "insert into child values (1, 1)")
+
+		if err := TwoPhaseCommit(ctx, a, b); err != nil {
+			t.Fatal(err)
+		}
+		a.Conn.Close()
+		b.Conn.Close()
+
+		if count := countRows(t, firstDB, "child"); count != 1 {
+			t.Fatalf("first: want 1 committed row, got %d", count)
+		}
+		if count := countRows(t, secondDB, "child"); count != 1 {
+			t.Fatalf("second: want 1 committed row, got %d", count)
+		}
+	})
+
+	t.Run("prepare fails", func(t *testing.T) {
+		a := beginParticipant(t, firstDB, "insert into child values (2, 1)")
+
+		//with wait_for_commit, referential integrity is only checked when preparing to commit
+		b := beginParticipant(t, secondDB,
+			"SET TEMPORARY OPTION wait_for_commit = 'On'",
+			"insert into child values (2, 99)",
+		)
+
+		err := TwoPhaseCommit(ctx, a, b)
+		commitErr, ok := err.(*CommitError)
+		if !ok {
+			t.Fatalf("want *CommitError, got %T: %v", err, err)
+		}
+		if commitErr.Prepared || commitErr.Failed != 1 {
+			t.Fatalf("want participant 1 to fail to prepare, got %+v", commitErr)
+		}
+
+		if _, err := b.Conn.ExecContext(ctx, "SET TEMPORARY OPTION wait_for_commit = 'Off'"); err != nil {
+			t.Fatal(err)
+		}
+		a.Conn.Close()
+		b.Conn.Close()
+
+		if count := countRows(t, firstDB, "child"); count != 1 {
+			t.Fatalf("first: want insert rolled back, got %d rows", count)
+		}
+		if count := countRows(t, secondDB, "child"); count != 1 {
+			t.Fatalf("second: want insert rolled back, got %d rows", count)
+		}
+	})
+}
+
+//beginParticipant begins a transaction on a new connection of db and executes statements in it
+func beginParticipant(t *testing.T, db *sql.DB, statements ...string) Participant {
+	ctx := context.Background()
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			t.Fatalf("%s: %v", statement, err)
+		}
+	}
+
+	return Participant{Conn: conn, Tx: tx}
+}
@@ -3,80 +3,216 @@ package sqlanywhere
 //#include <driver.c>
 import "C"
 import (
+	"context"
 	"database/sql"
 	"database/sql/driver"
+	"errors"
 	"fmt"
-	"log"
+	"strconv"
 )
 
 type tx struct {
-	con           *connection
-	opts          driver.TxOptions
-	lastIsolation driverIsolationLevel
+	con   *connection
+	opts  driver.TxOptions
+	ctx   context.Context
+	state txState
 }
 
+//txState is the state of a transaction; once no longer active, it cannot be committed or rolled back
+type txState int
+
+const (
+	txActive txState = iota
+	txCommitted
+	txRolledBack
+	txAborted //rolled back after a failed commit, or by ResetSession
+)
+
+//ErrSnapshotIsolationDisabled is returned when beginning a transaction with a snapshot isolation level
+//while the database option allow_snapshot_isolation is off
+var ErrSnapshotIsolationDisabled = errors.New("snapshot isolation is disabled: set the database option allow_snapshot_isolation to On")
+
+//ErrReadOnlyTransaction is returned when committing a read only transaction that has written to the database.
+//The writes are rolled back.
+//
+//It is a fallback for read only transactions the server does not enforce, those with an isolation level
+//other than readonly-statement-snapshot: their statements are not prevented from writing, so writes still
+//take locks, fire triggers and wait on other transactions until the transaction ends.
+var ErrReadOnlyTransaction = errors.New("read only transaction has uncommitted writes: rolled back")
+
+//Isolation levels specific to sqlanywhere, for use in sql.TxOptions.
+//See http://dcx.sap.com/1200/en/dbusage/transact-s-3847634.html
+const (
+	//LevelStatementSnapshot gives each statement a snapshot of the data committed when it starts
+	LevelStatementSnapshot sql.IsolationLevel = 100 + iota
+
+	//LevelReadOnlyStatementSnapshot gives each read only statement a snapshot of the data committed
+	//when it starts. Updatable statements use the isolation level of the updatable_statement_isolation option.
+	LevelReadOnlyStatementSnapshot
+)
+
 type driverIsolationLevel string
 
 const (
 	levelReadUncommitted           driverIsolationLevel = "0"
-	levelReadCommitted                                  = "1"
-	levelRepeatableRead                                 = "2"
-	levelSerializable                                   = "3"
-	levelSnapshot                                       = "snapshot"
-	levelStatementSnapshot                              = "statement-snapshot"
-	levelReadOnlyStatementSnapshot                      = "readonly-statement-snapshot"
+	levelReadCommitted             driverIsolationLevel = "1"
+	levelRepeatableRead            driverIsolationLevel = "2"
+	levelSerializable              driverIsolationLevel = "3"
+	levelSnapshot                  driverIsolationLevel = "snapshot"
+	levelStatementSnapshot         driverIsolationLevel = "statement-snapshot"
+	levelReadOnlyStatementSnapshot driverIsolationLevel = "readonly-statement-snapshot"
 )
 
-//TODO set snapshot isolation level, see: http://dcx.sap.com/1200/en/dbusage/transact-s-3847634.html
+//isSnapshot reports whether the level is one of the snapshot levels, which require allow_snapshot_isolation
+func (level driverIsolationLevel) isSnapshot() bool {
+	switch level {
+	case levelSnapshot, levelStatementSnapshot, levelReadOnlyStatementSnapshot:
+		return true
+	}
+	return false
+}
+
+//isolationLevels maps supported levels to sqlanywhere levels.
+//sql.LevelDefault is absent because it leaves the connection's level unchanged.
 var isolationLevels = map[sql.IsolationLevel]driverIsolationLevel{
-	sql.LevelDefault:         levelReadUncommitted,
 	sql.LevelReadUncommitted: levelReadUncommitted,
 	sql.LevelReadCommitted:   levelReadCommitted,
 	// sql.LevelWriteCommitted
-	sql.LevelRepeatableRead: levelRepeatableRead,
-	sql.LevelSnapshot:       levelSnapshot,
-	sql.LevelSerializable:   levelSerializable,
+	sql.LevelRepeatableRead:        levelRepeatableRead,
+	sql.LevelSnapshot:              levelSnapshot,
+	sql.LevelSerializable:          levelSerializable,
+	LevelStatementSnapshot:         levelStatementSnapshot,
+	LevelReadOnlyStatementSnapshot: levelReadOnlyStatementSnapshot,
 	// sql.LevelLinearizable
 }
 
+//Commit commits the transaction, unless the context given to BeginTx has finished,
+//in which case the transaction is rolled back and the context's error returned.
 func (t *tx) Commit() error {
-	defer t.restoreIsolationLevel()
+	if t.state != txActive {
+		return sql.ErrTxDone
+	}
 
-	if C.sqlany_commit(t.con.ptr) == 0 {
-		return t.con.lasterr("did not commit")
+	trace := t.con.startTrace(t.ctx, OpCommit, "", 0)
+	err := t.commitOrAbort()
+	trace.end(0, err)
+	return err
+}
+
+//commitOrAbort commits the transaction, rolling it back if it cannot be committed
+func (t *tx) commitOrAbort() error {
+	if err := t.ctx.Err(); err != nil {
+		return t.abort(err)
 	}
 
-	return nil
+	if t.opts.ReadOnly {
+		if err := t.checkReadOnly(); err != nil {
+			return t.abort(err)
+		}
+	}
+
+	if err := t.con.awaitFunc(t.ctx, t.commit); err != nil {
+		return t.abort(err)
+	}
+
+	t.state = txCommitted
+	return t.end(nil)
 }
 
+//Rollback rolls back the transaction. It proceeds even if the context given to BeginTx
+//has finished, since that is a common reason to roll back.
 func (t *tx) Rollback() error {
-	defer func() {
-		if err := t.restoreIsolationLevel(); err != nil {
-			log.Println("error during restore in rollback", err)
-		}
-	}()
+	if t.state != txActive {
+		return sql.ErrTxDone
+	}
+
+	trace := t.con.startTrace(t.ctx, OpRollback, "", 0)
+
+	t.state = txRolledBack
+	err := t.end(t.rollbackContext())
+
+	trace.end(0, err)
+	return err
+}
+
+//abort rolls back the transaction after a failed commit, returning err
+func (t *tx) abort(err error) error {
+	t.state = txAborted
 
-	var err error
+	if rollbackErr := t.rollbackContext(); rollbackErr != nil {
+		err = fmt.Errorf("%w; %v", err, rollbackErr)
+	}
+	return t.end(err)
+}
 
-	if err := t.con.lasterr("last err"); err != nil {
-		panic(err)
+func (t *tx) commit() error {
+	if C.sqlany_commit(t.con.ptr) == 0 {
+		return t.con.lasterr("did not commit")
 	}
+	return nil
+}
 
+func (t *tx) rollback() error {
 	if C.sqlany_rollback(t.con.ptr) == 0 {
-		err = t.con.lasterr("did not rollback")
-		if err != nil {
-			log.Println("error during rollback", err)
-		}
-		return err
+		return t.con.lasterr("did not rollback")
+	}
+	return nil
+}
+
+//rollbackContext rolls back with the context given to BeginTx, unless it has finished.
+//If the rollback fails the state of the connection is unknown, so it is marked bad.
+func (t *tx) rollbackContext() error {
+	ctx := t.ctx
+	if ctx.Err() != nil {
+		ctx = context.Background()
 	}
 
+	err := t.con.awaitFunc(ctx, t.rollback)
+	if err != nil {
+		t.con.valid = false
+	}
 	return err
 }
 
-func (t *tx) restoreIsolationLevel() error {
-	err := t.con.execImmediate("SET TEMPORARY OPTION isolation_level = " + string(t.lastIsolation))
+//checkReadOnly returns ErrReadOnlyTransaction if the transaction has uncommitted writes,
+//as counted by the UncommitOp connection property when the transaction is committed
+func (t *tx) checkReadOnly() error {
+	uncommitted, err := t.con.option("UncommitOp")
 	if err != nil {
-		return fmt.Errorf("did not set isolation level: %v", err)
+		return fmt.Errorf("did not check read only transaction: %w", err)
+	}
+
+	n, err := strconv.Atoi(uncommitted)
+	if err != nil {
+		return fmt.Errorf("did not check read only transaction: UncommitOp %q: %v", uncommitted, err)
+	}
+	if n > 0 {
+		return ErrReadOnlyTransaction
 	}
 	return nil
 }
+
+//end marks the transaction as no longer outstanding on the connection, restoring autocommit
+//and the isolation level. If they cannot be restored the connection is marked bad, so the
+//pool discards it. Restore errors are added to err.
+func (t *tx) end(err error) error {
+	if t.con.tx == t {
+		t.con.tx = nil
+	}
+
+	restoreErr := t.con.setAutocommit(t.con.autocommitDefault())
+	if restoreErr == nil {
+		restoreErr = t.con.restoreIsolation()
+	}
+
+	if restoreErr == nil {
+		return err
+	}
+
+	t.con.valid = false
+
+	if err == nil {
+		return fmt.Errorf("did not restore connection after transaction: %v", restoreErr)
+	}
+	return fmt.Errorf("%w; did not restore connection after transaction: %v", err, restoreErr)
+}
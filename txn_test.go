@@ -3,6 +3,7 @@ package sqlanywhere
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"strings"
 	"testing"
 	"time"
@@ -198,3 +199,135 @@ func (tp *TestPool) newTx(level sql.IsolationLevel) *sql.Tx {
 	tp.check(err)
 	return tx
 }
+
+func TestDefaultIsolation(t *testing.T) {
+	testdb := NewTestDB(t)
+	defer testdb.Cleanup()
+
+	db, close := testdb.Open()
+	defer close()
+	db.SetMaxOpenConns(1)
+
+	//a database default other than the driver's former default of read uncommitted
+	if _, err := db.Exec("SET OPTION PUBLIC.isolation_level = 1"); err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxIdleConns(0) //new connections pick up the changed public option
+
+	t.Run("database default", func(t *testing.T) {
+		if got := txIsolation(t, db, nil); got != "1" {
+			t.Fatalf("want database default isolation 1, got %q", got)
+		}
+	})
+
+	t.Run("restored after transaction", func(t *testing.T) {
+		db.SetMaxIdleConns(1)
+		defer db.SetMaxIdleConns(0)
+
+		if got := txIsolation(t, db, &sql.TxOptions{Isolation: sql.LevelSerializable}); got != "3" {
+			t.Fatalf("want serializable isolation 3, got %q", got)
+		}
+		if got := txIsolation(t, db, nil); got != "1" {
+			t.Fatalf("want isolation restored to 1, got %q", got)
+		}
+	})
+
+	t.Run("connector default", func(t *testing.T) {
+		connector, err := NewConnector(testdb.ConnectionString(), WithDefaultIsolation(sql.LevelRepeatableRead))
+		if err != nil {
+			t.Fatal(err)
+		}
+		repeatable := sql.OpenDB(connector)
+		defer repeatable.Close()
+
+		if got := txIsolation(t, repeatable, nil); got != "2" {
+			t.Fatalf("want connector default isolation 2, got %q", got)
+		}
+	})
+}
+
+//txIsolation returns the isolation level seen within a transaction begun with opts
+func txIsolation(t *testing.T, db *sql.DB, opts *sql.TxOptions) string {
+	tx, err := db.BeginTx(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	var level string
+	if err := tx.QueryRow("select connection_property('isolation_level')").Scan(&level); err != nil {
+		t.Fatal(err)
+	}
+	return level
+}
+
+func TestTransactionState(t *testing.T) {
+	testdb := NewTestDB(t)
+	defer testdb.Cleanup()
+
+	db, close := testdb.Open()
+	defer close()
+
+	if _, err := db.Exec("create table state_test (id int primary key)"); err != nil {
+		t.Fatalf("did not create table: %v", err)
+	}
+
+	t.Run("done", func(t *testing.T) {
+		withRawConnection(t, db, func(con *connection) {
+			tx, err := con.BeginTx(context.Background(), driver.TxOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := tx.Commit(); err != nil {
+				t.Fatal(err)
+			}
+			if err := tx.Commit(); err != sql.ErrTxDone {
+				t.Fatalf("second commit: want %v, got %v", sql.ErrTxDone, err)
+			}
+			if err := tx.Rollback(); err != sql.ErrTxDone {
+				t.Fatalf("rollback after commit: want %v, got %v", sql.ErrTxDone, err)
+			}
+		})
+	})
+
+	t.Run("commit with finished context", func(t *testing.T) {
+		withRawConnection(t, db, func(con *connection) {
+			ctx, cancel := context.WithCancel(context.Background())
+
+			tx, err := con.BeginTx(ctx, driver.TxOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := con.execImmediate("insert into state_test values (1)"); err != nil {
+				t.Fatal(err)
+			}
+
+			cancel()
+
+			if err := tx.Commit(); err != context.Canceled {
+				t.Fatalf("want %v, got %v", context.Canceled, err)
+			}
+		})
+
+		var count int
+		if err := db.QueryRow("select count(*) from state_test").Scan(&count); err != nil {
+			t.Fatal(err)
+		}
+		if count != 0 {
+			t.Fatalf("want insert rolled back, got %d rows", count)
+		}
+	})
+
+	t.Run("rollback after error", func(t *testing.T) {
+		tx, err := db.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tx.Exec("insert into no_such_table values (1)"); err == nil {
+			t.Fatal("inserted into missing table")
+		}
+		if err := tx.Rollback(); err != nil {
+			t.Fatalf("did not rollback after error: %v", err)
+		}
+	})
+}